@@ -105,11 +105,11 @@ func (as *Server) ValidateToken(w http.ResponseWriter, r *http.Request) (ti oaut
 		switch err {
 		case errors.ErrInvalidAccessToken:
 		case errors.ErrExpiredRefreshToken:
-			err = errorx.NewCodeError(err, UnauthorizedErrorCode)
+			err = errorx.Wrap(err, UnauthorizedErrorCode, errorx.WithCategory(errorx.CategoryUnauthorized))
 		case errors.ErrExpiredAccessToken:
-			err = errorx.NewCodeError(err, TokenExpiredErrorCode)
+			err = errorx.Wrap(err, TokenExpiredErrorCode, errorx.WithCategory(errorx.CategoryUnauthorized))
 		default:
-			err = errorx.NewCodeError(err, UnauthorizedErrorCode)
+			err = errorx.Wrap(err, UnauthorizedErrorCode, errorx.WithCategory(errorx.CategoryUnauthorized))
 		}
 		return
 	}
@@ -1,6 +1,9 @@
 package cachex
 
-import "github.com/fidelfly/gox/cachex/bcache"
+import (
+	"github.com/fidelfly/gox/cachex/bcache"
+	"github.com/fidelfly/gox/cachex/rcache"
+)
 
 //export
 func NewBuntCache(filename string) *bcache.BuntCache {
@@ -10,3 +13,12 @@ func NewBuntCache(filename string) *bcache.BuntCache {
 	}
 	return cache
 }
+
+//export
+func NewRedisCache(addr string) *rcache.RedisCache {
+	cache, err := rcache.NewCache(addr)
+	if err != nil {
+		return nil
+	}
+	return cache
+}
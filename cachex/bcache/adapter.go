@@ -0,0 +1,22 @@
+package bcache
+
+// CacheAdapter adapts BuntCache to cachex.Cache, Get returns the decoded value instead of the encoded string.
+type CacheAdapter struct {
+	*BuntCache
+}
+
+//export
+// AsCache returns bc as cachex.Cache, bc keeps its own methods for the existing callers.
+func AsCache(bc *BuntCache) *CacheAdapter {
+	return &CacheAdapter{BuntCache: bc}
+}
+
+// Get returns the value decoded without the type information, e.g. the json object is
+// map[string]interface{}, use GetObject to decode it into the typed value.
+func (ca *CacheAdapter) Get(key string) (interface{}, error) {
+	var val interface{}
+	if err := ca.GetObject(key, &val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
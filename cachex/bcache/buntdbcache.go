@@ -2,15 +2,24 @@ package bcache
 
 import (
 	"encoding/json"
+	"sync"
+	"time"
 
 	"github.com/tidwall/buntdb"
+
+	"github.com/fidelfly/gox/logx"
 )
 
 //https://github.com/tidwall/buntdb
 
+var ErrNotFound = buntdb.ErrNotFound
+
 type BuntCache struct {
-	db       *buntdb.DB
-	portions []Portion
+	db        *buntdb.DB
+	portions  []Portion
+	ttl       time.Duration
+	onEvicted func(string, string)
+	evictLock sync.RWMutex
 }
 
 type Portion interface {
@@ -89,14 +98,31 @@ func (bc *BuntCache) GetDB() *buntdb.DB {
 	return bc.db
 }
 
+// SetDefaultTTL sets the expiration used by Set, zero means the value never expires.
+func (bc *BuntCache) SetDefaultTTL(ttl time.Duration) {
+	bc.ttl = ttl
+}
+
 func (bc *BuntCache) Set(key string, obj interface{}) error {
+	return bc.SetWithTTL(key, obj, bc.ttl)
+}
+
+// SetWithTTL stores the object which will be expired after ttl, non-positive ttl means never expire.
+func (bc *BuntCache) SetWithTTL(key string, obj interface{}, ttl time.Duration) error {
+	dp := bc.findPortion(key)
+	if dp == nil {
+		dp = DefaultPortion
+	}
+	data, err := dp.Encode(obj)
+	if err != nil {
+		return err
+	}
+	var opts *buntdb.SetOptions
+	if ttl > 0 {
+		opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
+	}
 	return bc.db.Update(func(tx *buntdb.Tx) error {
-		dp := bc.findPortion(key)
-		if dp == nil {
-			dp = DefaultPortion
-		}
-		data, err := dp.Encode(obj)
-		_, _, err = tx.Set(key, data, nil)
+		_, _, err := tx.Set(key, data, opts)
 		return err
 	})
 }
@@ -121,11 +147,102 @@ func (bc *BuntCache) GetObject(key string, val interface{}) (err error) {
 	return
 }
 
-func (bc *BuntCache) Delete(key string) error {
+func (bc *BuntCache) Exists(key string) bool {
+	_, err := bc.Get(key)
+	return err == nil
+}
+
+func (bc *BuntCache) Clear() error {
 	return bc.db.Update(func(tx *buntdb.Tx) error {
-		_, err := tx.Delete(key)
+		return tx.DeleteAll()
+	})
+}
+
+// TTL returns the remaining time to live of the key, zero is returned if the key never expires.
+func (bc *BuntCache) TTL(key string) (ttl time.Duration, err error) {
+	err = bc.db.View(func(tx *buntdb.Tx) error {
+		ttl, err = tx.TTL(key)
 		return err
 	})
+	if ttl < 0 {
+		ttl = 0
+	}
+	return
+}
+
+// SetOnEvicted sets the function called with the key and value when the item is expired
+// or deleted manually, the expired items are checked by buntdb every second.
+func (bc *BuntCache) SetOnEvicted(f func(string, string)) {
+	bc.evictLock.Lock()
+	bc.onEvicted = f
+	bc.evictLock.Unlock()
+
+	var config buntdb.Config
+	if err := bc.db.ReadConfig(&config); err != nil {
+		logx.Error(err)
+		return
+	}
+	if f != nil {
+		config.OnExpired = bc.evictExpired
+	} else {
+		config.OnExpired = nil
+	}
+	logx.CaptureError(bc.db.SetConfig(config))
+}
+
+func (bc *BuntCache) getOnEvicted() func(string, string) {
+	bc.evictLock.RLock()
+	defer bc.evictLock.RUnlock()
+	return bc.onEvicted
+}
+
+// buntdb leaves the expired items to OnExpired, so they are deleted here.
+func (bc *BuntCache) evictExpired(keys []string) {
+	evicted := make(map[string]string, len(keys))
+	logx.CaptureError(bc.db.Update(func(tx *buntdb.Tx) error {
+		for _, key := range keys {
+			// the key may be deleted or set again before the eviction
+			if _, err := tx.TTL(key); err != buntdb.ErrNotFound {
+				continue
+			}
+			// Get ignores the expired items, but the iteration doesn't
+			val, found := "", false
+			if err := tx.AscendEqual("", key, func(k, v string) bool {
+				val, found = v, true
+				return false
+			}); err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+			if _, err := tx.Delete(key); err != nil && err != buntdb.ErrNotFound {
+				return err
+			}
+			evicted[key] = val
+		}
+		return nil
+	}))
+
+	if onEvicted := bc.getOnEvicted(); onEvicted != nil {
+		for key, val := range evicted {
+			onEvicted(key, val)
+		}
+	}
+}
+
+func (bc *BuntCache) Delete(key string) error {
+	var val string
+	err := bc.db.Update(func(tx *buntdb.Tx) (err error) {
+		val, err = tx.Delete(key)
+		return err
+	})
+	if err == nil {
+		if onEvicted := bc.getOnEvicted(); onEvicted != nil {
+			onEvicted(key, val)
+		}
+	}
+	return err
 }
 
 func (bc *BuntCache) Iterate(iterator func(key, val string) bool) {
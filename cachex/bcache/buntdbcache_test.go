@@ -0,0 +1,68 @@
+package bcache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuntCache_TTL(t *testing.T) {
+	bc, err := NewCache(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.GetDB().Close()
+
+	if err = bc.SetWithTTL("session", "data", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := bc.TTL("session"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL() = %v, %v", ttl, err)
+	}
+	if err = bc.Set("forever", "data"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := bc.TTL("forever"); err != nil || ttl != 0 {
+		t.Errorf("TTL() = %v, %v", ttl, err)
+	}
+	if _, err := bc.TTL("missing"); err != ErrNotFound {
+		t.Errorf("TTL() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestBuntCache_SetOnEvicted(t *testing.T) {
+	bc, err := NewCache(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.GetDB().Close()
+
+	evicted := make(chan [2]string, 2)
+	bc.SetOnEvicted(func(key string, val string) {
+		evicted <- [2]string{key, val}
+	})
+
+	if err = bc.SetWithTTL("expired", "old", 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err = bc.Set("deleted", "gone"); err != nil {
+		t.Fatal(err)
+	}
+	if err = bc.Delete("deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"expired": `"old"`, "deleted": `"gone"`}
+	for i := 0; i < len(want); i++ {
+		select {
+		case kv := <-evicted:
+			if want[kv[0]] != kv[1] {
+				t.Errorf("evicted %s = %s, want %s", kv[0], kv[1], want[kv[0]])
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("eviction is not notified")
+		}
+	}
+	if bc.Exists("expired") {
+		t.Errorf("expired key still exists")
+	}
+}
@@ -0,0 +1,155 @@
+package cachex
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"github.com/fidelfly/gox/cachex/bcache"
+	"github.com/fidelfly/gox/cachex/mcache"
+	"github.com/fidelfly/gox/cachex/rcache"
+	"github.com/fidelfly/gox/pkg/reflectx"
+)
+
+// Cache is the common behaviour of the memory, buntdb and redis caches.
+type Cache interface {
+	// Stores the value with the default expiration of the cache.
+	Set(key string, value interface{}) error
+
+	// Stores the value which will be expired after ttl, non-positive ttl means never expire.
+	SetWithTTL(key string, value interface{}, ttl time.Duration) error
+
+	// Returns the cached value, ErrNotFound is returned if the key doesn't exist.
+	// The buntdb and redis caches decode the value without the type information.
+	Get(key string) (interface{}, error)
+
+	// Decodes the cached value into target, ErrNotFound is returned if the key doesn't exist.
+	GetObject(key string, target interface{}) error
+
+	Exists(key string) bool
+
+	Delete(key string) error
+
+	// Removes all the values of the cache.
+	Clear() error
+}
+
+var (
+	_ Cache = (*mcache.CacheAdapter)(nil)
+	_ Cache = (*bcache.CacheAdapter)(nil)
+	_ Cache = (*rcache.RedisCache)(nil)
+)
+
+var ErrNotFound = bcache.ErrNotFound
+
+//export
+func IsNotFound(err error) bool {
+	return err == bcache.ErrNotFound || err == mcache.ErrNotFound
+}
+
+// Loader resolves the value of a key which is not found in the cache.
+type Loader func(key string) (interface{}, error)
+
+// LoadingCache fills the missing values with the loader when they are read.
+type LoadingCache struct {
+	Cache
+	loader Loader
+	ttl    time.Duration
+}
+
+//export
+// WithLoader attaches the loader to the cache, the loaded value is stored with ttl,
+// or with the default expiration of the cache if ttl is zero.
+func WithLoader(cache Cache, loader Loader, ttl time.Duration) *LoadingCache {
+	return &LoadingCache{Cache: cache, loader: loader, ttl: ttl}
+}
+
+func (lc *LoadingCache) load(key string) (interface{}, error) {
+	value, err := lc.loader(key)
+	if err != nil {
+		return nil, err
+	}
+	if reflectx.IsValueNil(value) {
+		return nil, ErrNotFound
+	}
+	if lc.ttl > 0 {
+		err = lc.Cache.SetWithTTL(key, value, lc.ttl)
+	} else {
+		err = lc.Cache.Set(key, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Get returns the cached value, the missing value is loaded and returned as it's returned by the loader.
+func (lc *LoadingCache) Get(key string) (interface{}, error) {
+	value, err := lc.Cache.Get(key)
+	if !IsNotFound(err) {
+		return value, err
+	}
+	return lc.load(key)
+}
+
+func (lc *LoadingCache) GetObject(key string, target interface{}) error {
+	err := lc.Cache.GetObject(key, target)
+	if !IsNotFound(err) {
+		return err
+	}
+	value, err := lc.load(key)
+	if err != nil {
+		return err
+	}
+	if reflectx.AssignValue(target, value) {
+		return nil
+	}
+	return lc.Cache.GetObject(key, target)
+}
+
+const (
+	MemoryCache = "memory"
+	BuntCache   = "bunt"
+	RedisCache  = "redis"
+)
+
+// Config selects and sets up the cache store.
+type Config struct {
+	Type       string
+	File       string // Used by bunt cache
+	Addr       string // Used by redis cache
+	Password   string
+	DB         int
+	Namespace  string
+	Expiration int // Default expiration in seconds, zero means never expire
+}
+
+//export
+// New creates the cache according to the config, memory cache is used if the type is empty.
+func New(config Config) (Cache, error) {
+	ttl := time.Duration(config.Expiration) * time.Second
+	switch config.Type {
+	case "", MemoryCache:
+		if ttl <= 0 {
+			ttl = mcache.NoExpiration
+		}
+		return mcache.AsCache(mcache.NewCache(ttl, 10*time.Minute)), nil
+	case BuntCache:
+		cache, err := bcache.NewCache(config.File)
+		if err != nil {
+			return nil, err
+		}
+		cache.SetDefaultTTL(ttl)
+		return bcache.AsCache(cache), nil
+	case RedisCache:
+		cache, err := rcache.NewCacheWithOptions(&redis.Options{Addr: config.Addr, Password: config.Password, DB: config.DB})
+		if err != nil {
+			return nil, err
+		}
+		cache.SetNamespace(config.Namespace)
+		cache.SetDefaultTTL(ttl)
+		return cache, nil
+	}
+	return nil, fmt.Errorf("unknown cache type : %s", config.Type)
+}
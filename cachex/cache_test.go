@@ -0,0 +1,105 @@
+package cachex
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fidelfly/gox/cachex/bcache"
+	"github.com/fidelfly/gox/cachex/mcache"
+)
+
+type testUser struct {
+	Name string
+	Age  int
+}
+
+func testCaches(t *testing.T) map[string]Cache {
+	bc, err := bcache.NewCache(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Cache{
+		"memory": mcache.AsCache(mcache.NewCache(mcache.NoExpiration, 0)),
+		"bunt":   bcache.AsCache(bc),
+	}
+}
+
+func TestCache(t *testing.T) {
+	for name, cache := range testCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := cache.Set("user:1", testUser{"alice", 30}); err != nil {
+				t.Fatal(err)
+			}
+			var user testUser
+			if err := cache.GetObject("user:1", &user); err != nil || user.Name != "alice" {
+				t.Errorf("GetObject() = %v, %v", user, err)
+			}
+			if !cache.Exists("user:1") {
+				t.Errorf("Exists() = false")
+			}
+			if value, err := cache.Get("user:1"); err != nil || value == nil {
+				t.Errorf("Get() = %v, %v", value, err)
+			}
+			if _, err := cache.Get("user:0"); !IsNotFound(err) {
+				t.Errorf("Get() of missing key error = %v", err)
+			}
+
+			if err := cache.SetWithTTL("user:2", testUser{"bob", 20}, 10*time.Millisecond); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(20 * time.Millisecond)
+			if err := cache.GetObject("user:2", &user); !IsNotFound(err) {
+				t.Errorf("GetObject() of expired key error = %v", err)
+			}
+
+			if err := cache.Clear(); err != nil {
+				t.Fatal(err)
+			}
+			if cache.Exists("user:1") {
+				t.Errorf("Exists() = true after Clear()")
+			}
+		})
+	}
+}
+
+func TestLoadingCache(t *testing.T) {
+	errLoad := errors.New("load failed")
+	for name, cache := range testCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			loads := 0
+			lc := WithLoader(cache, func(key string) (interface{}, error) {
+				loads++
+				switch key {
+				case "user:1":
+					return testUser{"alice", 30}, nil
+				case "user:2":
+					return nil, errLoad
+				}
+				return nil, nil
+			}, time.Minute)
+
+			var user testUser
+			for i := 0; i < 2; i++ {
+				if err := lc.GetObject("user:1", &user); err != nil || user.Name != "alice" {
+					t.Errorf("GetObject() = %v, %v", user, err)
+				}
+			}
+			if value, err := lc.Get("user:1"); err != nil || value == nil {
+				t.Errorf("Get() = %v, %v", value, err)
+			}
+			if loads != 1 {
+				t.Errorf("loader is called %d times, want 1", loads)
+			}
+			if err := lc.GetObject("user:2", &user); err != errLoad {
+				t.Errorf("GetObject() error = %v, want %v", err, errLoad)
+			}
+			if err := lc.GetObject("user:3", &user); !IsNotFound(err) {
+				t.Errorf("GetObject() error = %v, want not found", err)
+			}
+			if value, err := lc.Get("user:4"); !IsNotFound(err) {
+				t.Errorf("Get() = %v, %v, want not found", value, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package mcache
+
+// CacheAdapter adapts MemCache to the error based interface of cachex.Cache,
+// ErrNotFound is returned where MemCache reports false.
+type CacheAdapter struct {
+	*MemCache
+}
+
+//export
+// AsCache returns mc as cachex.Cache, mc keeps its own methods for the existing callers.
+func AsCache(mc *MemCache) *CacheAdapter {
+	return &CacheAdapter{MemCache: mc}
+}
+
+func (ca *CacheAdapter) Set(key string, value interface{}) error {
+	ca.MemCache.Set(key, value)
+	return nil
+}
+
+// Get returns the cached value, the value is resolved if the resolver is set,
+// the error of the resolver is returned as it is.
+func (ca *CacheAdapter) Get(key string) (interface{}, error) {
+	if ca.resolve == nil {
+		if value, ok := ca.TryGet(key); ok {
+			return value, nil
+		}
+		return nil, ErrNotFound
+	}
+	return ca.Resolve(key)
+}
@@ -0,0 +1,284 @@
+package mcache
+
+import (
+	"container/heap"
+	"container/list"
+	"reflect"
+	"sync"
+)
+
+// Stats reports the usage of the cache.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64 // entries evicted due to the size limit
+	Entries   int    // count of the cached entries
+	Bytes     int64  // approximate size of the entries, only calculated with the size limit
+}
+
+type policy interface {
+	add(key string)
+	access(key string)
+	remove(key string)
+	victim() (string, bool)
+	reset()
+}
+
+func newPolicy(p EvictionPolicy) policy {
+	if p == LFU {
+		return newLfuPolicy()
+	}
+	return newLruPolicy()
+}
+
+// cacheBound keeps the cache within the limit of the entry count and size.
+type cacheBound struct {
+	maxEntries int
+	maxBytes   int64
+	policy     policy
+	sizer      Sizer
+	sizes      map[string]int64
+	bytes      int64
+	lock       sync.Mutex
+}
+
+func newCacheBound() *cacheBound {
+	return &cacheBound{
+		policy: newLruPolicy(),
+		sizer:  approximateSize,
+		sizes:  make(map[string]int64),
+	}
+}
+
+func (cb *cacheBound) limited() bool {
+	return cb.maxEntries > 0 || cb.maxBytes > 0
+}
+
+// track records the entry and returns the keys which should be evicted to make room for it.
+// An entry which is set again is tracked as a new one.
+func (cb *cacheBound) track(key string, value interface{}) (victims []string) {
+	size := cb.sizer(key, value)
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	cb.untrackLocked(key)
+
+	for (cb.maxEntries > 0 && len(cb.sizes) >= cb.maxEntries) || (cb.maxBytes > 0 && cb.bytes+size > cb.maxBytes) {
+		victim, ok := cb.policy.victim()
+		if !ok {
+			break
+		}
+		cb.untrackLocked(victim)
+		victims = append(victims, victim)
+	}
+
+	cb.policy.add(key)
+	cb.sizes[key] = size
+	cb.bytes += size
+	return
+}
+
+func (cb *cacheBound) access(key string) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if _, ok := cb.sizes[key]; ok {
+		cb.policy.access(key)
+	}
+}
+
+func (cb *cacheBound) untrack(key string) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	cb.untrackLocked(key)
+}
+
+func (cb *cacheBound) untrackLocked(key string) {
+	if size, ok := cb.sizes[key]; ok {
+		cb.bytes -= size
+		delete(cb.sizes, key)
+		cb.policy.remove(key)
+	}
+}
+
+func (cb *cacheBound) reset() {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	cb.sizes = make(map[string]int64)
+	cb.bytes = 0
+	cb.policy.reset()
+}
+
+func (cb *cacheBound) usage() (int, int64) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	return len(cb.sizes), cb.bytes
+}
+
+type lruPolicy struct {
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+func newLruPolicy() *lruPolicy {
+	return &lruPolicy{order: list.New(), elements: make(map[string]*list.Element)}
+}
+
+func (lp *lruPolicy) add(key string) {
+	lp.elements[key] = lp.order.PushFront(key)
+}
+
+func (lp *lruPolicy) access(key string) {
+	if e, ok := lp.elements[key]; ok {
+		lp.order.MoveToFront(e)
+	}
+}
+
+func (lp *lruPolicy) remove(key string) {
+	if e, ok := lp.elements[key]; ok {
+		lp.order.Remove(e)
+		delete(lp.elements, key)
+	}
+}
+
+func (lp *lruPolicy) victim() (string, bool) {
+	if e := lp.order.Back(); e != nil {
+		return e.Value.(string), true
+	}
+	return "", false
+}
+
+func (lp *lruPolicy) reset() {
+	lp.order.Init()
+	lp.elements = make(map[string]*list.Element)
+}
+
+type lfuEntry struct {
+	key   string
+	freq  uint64
+	seq   uint64
+	index int
+}
+
+// lfuHeap is a min heap ordered by frequency and then by the last access sequence.
+type lfuHeap []*lfuEntry
+
+func (h lfuHeap) Len() int { return len(h) }
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].freq < h[j].freq
+}
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *lfuHeap) Push(x interface{}) {
+	e := x.(*lfuEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
+
+type lfuPolicy struct {
+	entries lfuHeap
+	keys    map[string]*lfuEntry
+	seq     uint64
+}
+
+func newLfuPolicy() *lfuPolicy {
+	return &lfuPolicy{keys: make(map[string]*lfuEntry)}
+}
+
+func (lp *lfuPolicy) add(key string) {
+	lp.seq++
+	e := &lfuEntry{key: key, freq: 1, seq: lp.seq}
+	heap.Push(&lp.entries, e)
+	lp.keys[key] = e
+}
+
+func (lp *lfuPolicy) access(key string) {
+	if e, ok := lp.keys[key]; ok {
+		lp.seq++
+		e.freq++
+		e.seq = lp.seq
+		heap.Fix(&lp.entries, e.index)
+	}
+}
+
+func (lp *lfuPolicy) remove(key string) {
+	if e, ok := lp.keys[key]; ok {
+		heap.Remove(&lp.entries, e.index)
+		delete(lp.keys, key)
+	}
+}
+
+func (lp *lfuPolicy) victim() (string, bool) {
+	if len(lp.entries) > 0 {
+		return lp.entries[0].key, true
+	}
+	return "", false
+}
+
+func (lp *lfuPolicy) reset() {
+	lp.entries = nil
+	lp.keys = make(map[string]*lfuEntry)
+}
+
+const maxSizeDepth = 8
+
+func approximateSize(key string, value interface{}) int64 {
+	return int64(len(key)) + 16 + sizeOf(reflect.ValueOf(value), 0)
+}
+
+// nolint:gocyclo
+func sizeOf(v reflect.Value, depth int) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+	if depth > maxSizeDepth {
+		return int64(v.Type().Size())
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Type().Size()) + int64(v.Len())
+	case reflect.Slice:
+		size := int64(v.Type().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		for i := 0; i < v.Len(); i++ {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Map:
+		size := int64(v.Type().Size())
+		for _, k := range v.MapKeys() {
+			size += sizeOf(k, depth+1) + sizeOf(v.MapIndex(k), depth+1)
+		}
+		return size
+	case reflect.Struct:
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += sizeOf(v.Field(i), depth+1)
+		}
+		return size
+	case reflect.Ptr, reflect.Interface:
+		size := int64(v.Type().Size())
+		if !v.IsNil() {
+			size += sizeOf(v.Elem(), depth+1)
+		}
+		return size
+	}
+	return int64(v.Type().Size())
+}
@@ -1,9 +1,17 @@
 package mcache
 
 import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	gocache "github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/fidelfly/gox/logx"
+	"github.com/fidelfly/gox/pkg/reflectx"
 )
 
 // Default value for cache configuration
@@ -17,62 +25,309 @@ const (
 	DefaultExpiration = gocache.DefaultExpiration
 )
 
+var ErrNotFound = errors.New("not found")
+var ErrTypeMismatch = errors.New("cached value can't be assigned to the target")
+
 type Resolver func(key string) interface{}
 
+// ResolveFunc is the resolver which is able to report the failure.
+type ResolveFunc func(key string) (interface{}, error)
+
 type MemCache struct {
+	// accessed atomically, keep them at the beginning for 64-bit alignment
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
 	cacheInstance *gocache.Cache
-	resolver      Resolver
+	resolve       ResolveFunc
+	flight        singleflight.Group
+	refreshFlight singleflight.Group
+	ttls          sync.Map
+	negativeTTL   time.Duration
+	refreshAhead  time.Duration
+	limit         *cacheBound
+	onEvicted     func(string, interface{})
+	evictLock     sync.RWMutex
+}
+
+// negativeEntry marks a key which is failed to be resolved.
+type negativeEntry struct {
+	err error
 }
 
+// SetOnEvicted sets the function called when the entry is expired, evicted due to the size limit
+// or deleted manually.
 func (mc *MemCache) SetOnEvicted(f func(string, interface{})) {
-	mc.cacheInstance.OnEvicted(f)
+	mc.evictLock.Lock()
+	defer mc.evictLock.Unlock()
+	mc.onEvicted = f
+}
+
+func (mc *MemCache) evicted(key string, value interface{}) {
+	mc.ttls.Delete(key)
+	if mc.limit != nil {
+		mc.limit.untrack(key)
+	}
+	if _, negative := value.(*negativeEntry); negative {
+		return
+	}
+	mc.evictLock.RLock()
+	f := mc.onEvicted
+	mc.evictLock.RUnlock()
+	if f != nil {
+		f(key, value)
+	}
 }
 
+// GetStore returns the underlying go-cache, the values set through it are not counted by the size limit.
 func (mc *MemCache) GetStore() *gocache.Cache {
 	return mc.cacheInstance
 }
 
+func (mc *MemCache) bound() *cacheBound {
+	if mc.limit == nil {
+		mc.limit = newCacheBound()
+	}
+	return mc.limit
+}
+
+func (mc *MemCache) store(key string, value interface{}, ttl time.Duration) {
+	// the ttl of the entry is kept for the refresh
+	if ttl == DefaultExpiration {
+		mc.ttls.Delete(key)
+	} else {
+		mc.ttls.Store(key, ttl)
+	}
+	mc.cacheInstance.Set(key, value, ttl)
+	if mc.limit != nil {
+		for _, victim := range mc.limit.track(key, value) {
+			atomic.AddUint64(&mc.evictions, 1)
+			mc.cacheInstance.Delete(victim)
+		}
+	}
+}
+
+func (mc *MemCache) recordAccess(key string, hit bool) {
+	if hit {
+		atomic.AddUint64(&mc.hits, 1)
+		if mc.limit != nil {
+			mc.limit.access(key)
+		}
+	} else {
+		atomic.AddUint64(&mc.misses, 1)
+	}
+}
+
+func (mc *MemCache) Stats() Stats {
+	stats := Stats{
+		Hits:      atomic.LoadUint64(&mc.hits),
+		Misses:    atomic.LoadUint64(&mc.misses),
+		Evictions: atomic.LoadUint64(&mc.evictions),
+	}
+	if mc.limit != nil {
+		stats.Entries, stats.Bytes = mc.limit.usage()
+	} else {
+		stats.Entries = mc.cacheInstance.ItemCount()
+	}
+	return stats
+}
+
 func (mc *MemCache) Remove(key string) {
 	mc.cacheInstance.Delete(key)
 }
 
 func (mc *MemCache) SetResolver(resovler Resolver) {
-	mc.resolver = resovler
+	if resovler == nil {
+		mc.resolve = nil
+		return
+	}
+	mc.resolve = func(key string) (interface{}, error) {
+		return resovler(key), nil
+	}
+}
+
+func (mc *MemCache) SetResolveFunc(resolve ResolveFunc) {
+	mc.resolve = resolve
+}
+
+// SetNegativeTTL makes the failure of the resolver cached for ttl,
+// so that the resolver is not called again for the key during that time. Zero disables it.
+func (mc *MemCache) SetNegativeTTL(ttl time.Duration) {
+	mc.negativeTTL = ttl
+}
+
+// SetRefreshAhead makes the entry which will be expired within the window reloaded in background,
+// the stale value is served until the reload is done. Zero disables it.
+func (mc *MemCache) SetRefreshAhead(window time.Duration) {
+	mc.refreshAhead = window
 }
 
 func (mc *MemCache) Set(key string, value interface{}) {
-	mc.cacheInstance.SetDefault(key, value)
+	mc.store(key, value, DefaultExpiration)
+}
+
+// SetWithTTL stores the value which will be expired after ttl, non-positive ttl means never expire.
+func (mc *MemCache) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = NoExpiration
+	}
+	mc.store(key, value, ttl)
+	return nil
+}
+
+// GetObject assigns the cached value to the variable obj points to.
+func (mc *MemCache) GetObject(key string, obj interface{}) error {
+	value, err := mc.Resolve(key)
+	if err != nil {
+		return err
+	}
+	if !reflectx.AssignValue(obj, value) {
+		return ErrTypeMismatch
+	}
+	return nil
+}
+
+func (mc *MemCache) Exists(key string) bool {
+	value, ok := mc.cacheInstance.Get(key)
+	if _, negative := value.(*negativeEntry); negative {
+		return false
+	}
+	return ok
+}
+
+func (mc *MemCache) Delete(key string) error {
+	mc.cacheInstance.Delete(key)
+	return nil
 }
 
+func (mc *MemCache) Clear() error {
+	mc.cacheInstance.Flush()
+	mc.ttls.Range(func(key, _ interface{}) bool {
+		mc.ttls.Delete(key)
+		return true
+	})
+	if mc.limit != nil {
+		mc.limit.reset()
+	}
+	return nil
+}
+
+// Get returns the cached value, the value is resolved if the resolver is set.
 func (mc *MemCache) Get(key string) (interface{}, bool) {
-	if mc.resolver == nil {
+	if mc.resolve == nil {
 		return mc.TryGet(key)
 	}
 	return mc.EnsureGet(key)
 }
 
 func (mc *MemCache) TryGet(key string) (interface{}, bool) {
-	return mc.cacheInstance.Get(key)
+	value, ok := mc.cacheInstance.Get(key)
+	if _, negative := value.(*negativeEntry); negative {
+		ok = false
+		value = nil
+	}
+	mc.recordAccess(key, ok)
+	return value, ok
 }
 
 func (mc *MemCache) EnsureGet(key string) (interface{}, bool) {
-	value, ok := mc.cacheInstance.Get(key)
-	if !ok && mc.resolver != nil {
-		value = mc.resolver(key)
-		ok = value != nil
-		if ok {
-			mc.Set(key, value)
+	value, err := mc.Resolve(key)
+	return value, err == nil
+}
+
+// Resolve returns the cached value, or the value returned by the resolver if the key is missing.
+// Concurrent calls for the same key share one resolver call.
+func (mc *MemCache) Resolve(key string) (interface{}, error) {
+	value, expiration, ok := mc.cacheInstance.GetWithExpiration(key)
+	if ok {
+		if ne, negative := value.(*negativeEntry); negative {
+			mc.recordAccess(key, false)
+			return nil, ne.err
+		}
+		mc.recordAccess(key, true)
+		if mc.resolve != nil && mc.refreshAhead > 0 && !expiration.IsZero() && time.Until(expiration) < mc.refreshAhead {
+			mc.refresh(key)
 		}
+		return value, nil
 	}
-	return value, ok
+	mc.recordAccess(key, false)
+	if mc.resolve == nil {
+		return nil, ErrNotFound
+	}
+	value, err, _ := mc.flight.Do(key, func() (interface{}, error) {
+		return mc.load(key)
+	})
+	return value, err
+}
+
+func (mc *MemCache) callResolver(key string) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic found during resolving %s : %v", key, r)
+		}
+	}()
+	value, err = mc.resolve(key)
+	if err == nil && reflectx.IsValueNil(value) {
+		err = ErrNotFound
+	}
+	return
+}
+
+func (mc *MemCache) load(key string) (interface{}, error) {
+	value, err := mc.callResolver(key)
+	if err != nil {
+		if mc.negativeTTL > 0 {
+			mc.store(key, &negativeEntry{err}, mc.negativeTTL)
+		}
+		return nil, err
+	}
+	mc.store(key, value, DefaultExpiration)
+	return value, nil
+}
+
+// refresh reloads the entry in background, it doesn't share the flight with the foreground loads,
+// so that its failure is not returned to them. The entry keeps its ttl.
+func (mc *MemCache) refresh(key string) {
+	mc.refreshFlight.DoChan(key, func() (interface{}, error) {
+		value, err := mc.callResolver(key)
+		if err != nil {
+			// keep serving the stale value
+			logx.Warnf("refresh cache key %s failed : %v", key, err)
+			return nil, err
+		}
+		ttl := DefaultExpiration
+		if v, ok := mc.ttls.Load(key); ok {
+			ttl = v.(time.Duration)
+		}
+		mc.store(key, value, ttl)
+		return value, nil
+	})
+}
+
+//export
+func NewCache(defaultExpiration, cleanupInterval time.Duration, opts ...Option) *MemCache {
+	mc := &MemCache{cacheInstance: gocache.New(defaultExpiration, cleanupInterval)}
+	for _, opt := range opts {
+		opt(mc)
+	}
+	if mc.limit != nil && !mc.limit.limited() {
+		mc.limit = nil
+	}
+	mc.cacheInstance.OnEvicted(mc.evicted)
+	return mc
 }
 
 //export
-func NewCache(defaultExpiration, cleanupInterval time.Duration) *MemCache {
-	return &MemCache{cacheInstance: gocache.New(defaultExpiration, cleanupInterval)}
+func NewEnsureCache(defaultExpiration, cleanupInterval time.Duration, resolver Resolver, opts ...Option) *MemCache {
+	mc := NewCache(defaultExpiration, cleanupInterval, opts...)
+	mc.SetResolver(resolver)
+	return mc
 }
 
 //export
-func NewEnsureCache(defaultExpiration, cleanupInterval time.Duration, resolver Resolver) *MemCache {
-	return &MemCache{cacheInstance: gocache.New(defaultExpiration, cleanupInterval), resolver: resolver}
+func NewResolveCache(defaultExpiration, cleanupInterval time.Duration, resolve ResolveFunc, opts ...Option) *MemCache {
+	mc := NewCache(defaultExpiration, cleanupInterval, opts...)
+	mc.SetResolveFunc(resolve)
+	return mc
 }
@@ -0,0 +1,218 @@
+package mcache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemCache_ResolveOnce(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	mc := NewResolveCache(time.Minute, 0, func(key string) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value of " + key, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := mc.Resolve("hot"); err != nil || v != "value of hot" {
+				t.Errorf("Resolve() = %v, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("resolver is called %d times, want 1", calls)
+	}
+}
+
+func TestMemCache_NegativeTTL(t *testing.T) {
+	errDB := errors.New("db is down")
+	var calls int32
+	mc := NewResolveCache(time.Minute, 0, func(key string) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errDB
+	})
+	mc.SetNegativeTTL(20 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if _, err := mc.Resolve("missing"); err != errDB {
+			t.Errorf("Resolve() error = %v, want %v", err, errDB)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("resolver is called %d times, want 1", calls)
+	}
+	if mc.Exists("missing") {
+		t.Errorf("Exists() = true for negative entry")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	_, _ = mc.Resolve("missing")
+	if calls != 2 {
+		t.Errorf("resolver is called %d times after negative ttl, want 2", calls)
+	}
+}
+
+// waitValue polls the cached value until it's want, the refresh runs in background.
+func waitValue(t *testing.T, mc *MemCache, key string, want interface{}) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		v, _ := mc.GetStore().Get(key)
+		if v == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cached value = %v, want %v", v, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMemCache_RefreshAhead(t *testing.T) {
+	var version int32
+	gate := make(chan struct{})
+	mc := NewResolveCache(time.Hour, 0, func(key string) (interface{}, error) {
+		v := atomic.AddInt32(&version, 1)
+		if v > 1 {
+			<-gate
+		}
+		return v, nil
+	})
+	// every hit is within the window, so it starts the refresh
+	mc.SetRefreshAhead(2 * time.Hour)
+
+	if v, _ := mc.Resolve("key"); v != int32(1) {
+		t.Fatalf("Resolve() = %v, want 1", v)
+	}
+	// the stale value is served until the refresh is done
+	if v, _ := mc.Resolve("key"); v != int32(1) {
+		t.Errorf("Resolve() = %v, want stale value 1", v)
+	}
+	close(gate)
+	waitValue(t, mc, "key", int32(2))
+
+	// the refresh keeps the ttl of the entry
+	_ = mc.SetWithTTL("ttl", int32(0), 10*time.Minute)
+	_, _ = mc.Resolve("ttl")
+	waitValue(t, mc, "ttl", int32(3))
+	if _, expiration, _ := mc.GetStore().GetWithExpiration("ttl"); time.Until(expiration) > 10*time.Minute {
+		t.Errorf("refreshed entry expires in %v, want 10m", time.Until(expiration))
+	}
+}
+
+func TestMemCache_RefreshFailure(t *testing.T) {
+	errDB := errors.New("db is down")
+	var calls int32
+	refreshing, gate := make(chan struct{}), make(chan struct{})
+	mc := NewResolveCache(time.Hour, 0, func(key string) (interface{}, error) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			return "first", nil
+		case 2:
+			close(refreshing)
+			<-gate
+			return nil, errDB
+		}
+		return "reloaded", nil
+	})
+	mc.SetRefreshAhead(2 * time.Hour)
+
+	_, _ = mc.Resolve("key")
+	if v, err := mc.Resolve("key"); err != nil || v != "first" {
+		t.Errorf("Resolve() = %v, %v, want stale value", v, err)
+	}
+	<-refreshing
+	// the foreground load doesn't wait for the refresh or get its error
+	_ = mc.Delete("key")
+	if v, err := mc.Resolve("key"); err != nil || v != "reloaded" {
+		t.Errorf("Resolve() = %v, %v, want reloaded", v, err)
+	}
+	close(gate)
+	time.Sleep(10 * time.Millisecond)
+	if v, _ := mc.GetStore().Get("key"); v != "reloaded" {
+		t.Errorf("cached value = %v after the failed refresh, want reloaded", v)
+	}
+}
+
+func TestMemCache_MaxEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  EvictionPolicy
+		evicted string
+	}{
+		{"LRU", LRU, "a"},
+		{"LFU", LFU, "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := NewCache(NoExpiration, 0, MaxEntries(3), WithPolicy(tt.policy))
+			var evicted []string
+			mc.SetOnEvicted(func(key string, value interface{}) {
+				evicted = append(evicted, key)
+			})
+			mc.Set("a", 1)
+			mc.Set("b", 2)
+			mc.Set("c", 3)
+			mc.Get("a")
+			mc.Get("b")
+			mc.Get("a")
+			mc.Get("b")
+			mc.Get("c")
+			mc.Set("d", 4)
+
+			if len(evicted) != 1 || evicted[0] != tt.evicted {
+				t.Errorf("evicted = %v, want [%s]", evicted, tt.evicted)
+			}
+			stats := mc.Stats()
+			if stats.Entries != 3 || stats.Evictions != 1 || stats.Hits != 5 {
+				t.Errorf("Stats() = %+v", stats)
+			}
+		})
+	}
+}
+
+func TestMemCache_MaxBytes(t *testing.T) {
+	mc := NewCache(NoExpiration, 0, MaxBytes(100), WithSizer(func(key string, value interface{}) int64 {
+		return int64(len(value.(string)))
+	}))
+	mc.Set("a", string(make([]byte, 40)))
+	mc.Set("b", string(make([]byte, 40)))
+	mc.Set("c", string(make([]byte, 40)))
+
+	if mc.Exists("a") || !mc.Exists("b") || !mc.Exists("c") {
+		t.Errorf("the oldest entry should be evicted")
+	}
+	if stats := mc.Stats(); stats.Bytes != 80 || stats.Entries != 2 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+	_ = mc.Delete("b")
+	if _, ok := mc.Get("x"); ok {
+		t.Errorf("Get() of missing key is ok")
+	}
+	if stats := mc.Stats(); stats.Bytes != 40 || stats.Misses != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
+
+func TestApproximateSize(t *testing.T) {
+	type item struct {
+		Name string
+		Tags []string
+	}
+	small := approximateSize("k", item{Name: "a"})
+	large := approximateSize("k", item{Name: "a", Tags: []string{"tag1", "tag2", "tag3"}})
+	if small <= 0 || large <= small {
+		t.Errorf("approximateSize() = %d, %d", small, large)
+	}
+}
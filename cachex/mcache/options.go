@@ -0,0 +1,48 @@
+package mcache
+
+type Option func(mc *MemCache)
+
+type EvictionPolicy int
+
+const (
+	// LRU evicts the least recently used entry
+	LRU EvictionPolicy = iota
+	// LFU evicts the least frequently used entry, the least recently used one is chosen among the equals
+	LFU
+)
+
+// Sizer returns the approximate size in bytes of the entry.
+type Sizer func(key string, value interface{}) int64
+
+//export
+// MaxEntries bounds the count of the cached entries, zero means no limit.
+func MaxEntries(n int) Option {
+	return func(mc *MemCache) {
+		mc.bound().maxEntries = n
+	}
+}
+
+//export
+// MaxBytes bounds the approximate size of the cached entries, zero means no limit.
+func MaxBytes(n int64) Option {
+	return func(mc *MemCache) {
+		mc.bound().maxBytes = n
+	}
+}
+
+//export
+// WithPolicy sets the policy used to choose the entry to be evicted, LRU is used by default.
+func WithPolicy(policy EvictionPolicy) Option {
+	return func(mc *MemCache) {
+		b := mc.bound()
+		b.policy = newPolicy(policy)
+	}
+}
+
+//export
+// WithSizer replaces the default reflection based size estimation used by MaxBytes.
+func WithSizer(sizer Sizer) Option {
+	return func(mc *MemCache) {
+		mc.bound().sizer = sizer
+	}
+}
@@ -0,0 +1,42 @@
+package rcache
+
+import (
+	"github.com/go-redis/redis"
+
+	"github.com/fidelfly/gox/cachex/bcache"
+)
+
+//export
+func NewCache(addr string, portions ...bcache.Portion) (*RedisCache, error) {
+	return NewCacheWithOptions(&redis.Options{Addr: addr}, portions...)
+}
+
+//export
+func NewCacheWithOptions(opts *redis.Options, portions ...bcache.Portion) (*RedisCache, error) {
+	client := redis.NewClient(opts)
+	if err := client.Ping().Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return NewCacheWithClient(client, portions...), nil
+}
+
+//export
+func NewCacheWithClient(client redis.UniversalClient, portions ...bcache.Portion) *RedisCache {
+	rc := &RedisCache{client: client}
+	rc.AddPortion(bcache.DefaultPortion)
+	if len(portions) > 0 {
+		rc.AddPortion(portions...)
+	}
+	return rc
+}
+
+//export
+func NewCacheWithConverter(addr string, converter bcache.Converter) (*RedisCache, error) {
+	rc, err := NewCache(addr)
+	if err != nil {
+		return nil, err
+	}
+	rc.SetDefaultConverter(converter)
+	return rc, nil
+}
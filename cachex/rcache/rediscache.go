@@ -0,0 +1,190 @@
+package rcache
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"github.com/fidelfly/gox/cachex/bcache"
+)
+
+//https://github.com/go-redis/redis
+
+var ErrNotFound = bcache.ErrNotFound
+
+const scanCount = 100
+
+type RedisCache struct {
+	client    redis.UniversalClient
+	portions  []bcache.Portion
+	namespace string
+	ttl       time.Duration
+}
+
+func (rc *RedisCache) findPortion(key string) bcache.Portion {
+	lop := len(rc.portions)
+	if lop > 0 {
+		for i := lop - 1; i >= 0; i-- {
+			ds := rc.portions[i]
+			if ds.Match(key) {
+				return ds
+			}
+		}
+	}
+	return bcache.DefaultPortion
+}
+
+func (rc *RedisCache) SetDefaultConverter(converter bcache.Converter) {
+	if len(rc.portions) > 0 {
+		rc.portions[0] = bcache.NewDataset("*", converter)
+	} else {
+		rc.AddPortion(bcache.NewDataset("*", converter))
+	}
+}
+
+func (rc *RedisCache) AddPortion(portions ...bcache.Portion) {
+	rc.portions = append(rc.portions, portions...)
+}
+
+// SetNamespace prefixes all the keys stored by the cache,
+// so that several caches can share one redis database.
+func (rc *RedisCache) SetNamespace(ns string) {
+	rc.namespace = ns
+}
+
+// SetDefaultTTL sets the expiration used by Set, zero means the value never expires.
+func (rc *RedisCache) SetDefaultTTL(ttl time.Duration) {
+	rc.ttl = ttl
+}
+
+func (rc *RedisCache) GetClient() redis.UniversalClient {
+	return rc.client
+}
+
+func (rc *RedisCache) Close() error {
+	return rc.client.Close()
+}
+
+func (rc *RedisCache) storeKey(key string) string {
+	if len(rc.namespace) > 0 {
+		return bcache.NewKey(rc.namespace, key)
+	}
+	return key
+}
+
+func (rc *RedisCache) cacheKey(storeKey string) string {
+	if len(rc.namespace) > 0 {
+		return storeKey[len(rc.namespace)+1:]
+	}
+	return storeKey
+}
+
+func (rc *RedisCache) Set(key string, obj interface{}) error {
+	return rc.SetWithTTL(key, obj, rc.ttl)
+}
+
+// SetWithTTL stores the object which will be expired after ttl, zero ttl means never expire.
+func (rc *RedisCache) SetWithTTL(key string, obj interface{}, ttl time.Duration) error {
+	data, err := rc.findPortion(key).Encode(obj)
+	if err != nil {
+		return err
+	}
+	return rc.client.Set(rc.storeKey(key), data, ttl).Err()
+}
+
+// GetString returns the encoded value of the key.
+func (rc *RedisCache) GetString(key string) (string, error) {
+	val, err := rc.client.Get(rc.storeKey(key)).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	return val, err
+}
+
+// Get returns the value decoded without the type information, e.g. the json object is
+// map[string]interface{}, use GetObject to decode it into the typed value.
+func (rc *RedisCache) Get(key string) (interface{}, error) {
+	var val interface{}
+	if err := rc.GetObject(key, &val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+func (rc *RedisCache) GetObject(key string, val interface{}) (err error) {
+	data, err := rc.GetString(key)
+	if err == nil {
+		err = rc.findPortion(key).Decode(data, val)
+	}
+	return
+}
+
+// TTL returns the remaining time to live of the key, zero is returned if the key never expires.
+func (rc *RedisCache) TTL(key string) (time.Duration, error) {
+	ttl, err := rc.client.PTTL(rc.storeKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// PTTL replies -2 for a missing key and -1 for a key without expiration
+	switch {
+	case ttl == -2*time.Millisecond:
+		return 0, ErrNotFound
+	case ttl < 0:
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (rc *RedisCache) Exists(key string) bool {
+	n, err := rc.client.Exists(rc.storeKey(key)).Result()
+	return err == nil && n > 0
+}
+
+// Clear removes all the keys within the namespace, or the whole database if there is no namespace.
+func (rc *RedisCache) Clear() error {
+	pattern := rc.storeKey("*")
+	var cursor uint64
+	for {
+		keys, next, err := rc.client.Scan(cursor, pattern, scanCount).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err = rc.client.Del(keys...).Err(); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func (rc *RedisCache) Delete(key string) error {
+	return rc.client.Del(rc.storeKey(key)).Err()
+}
+
+func (rc *RedisCache) Iterate(iterator func(key, val string) bool) {
+	pattern := rc.storeKey("*")
+	var cursor uint64
+	for {
+		keys, next, err := rc.client.Scan(cursor, pattern, scanCount).Result()
+		if err != nil {
+			return
+		}
+		for _, key := range keys {
+			val, err := rc.client.Get(key).Result()
+			if err != nil {
+				continue
+			}
+			if !iterator(rc.cacheKey(key), val) {
+				return
+			}
+		}
+		if next == 0 {
+			return
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,99 @@
+package rcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+type testObject struct {
+	Name  string
+	Count int
+}
+
+func newTestCache(t *testing.T) (*miniredis.Miniredis, *RedisCache) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mr, NewCacheWithClient(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+}
+
+func TestRedisCache_SetGet(t *testing.T) {
+	mr, rc := newTestCache(t)
+	defer mr.Close()
+	rc.SetNamespace("test")
+
+	if err := rc.Set("obj:1", testObject{"first", 1}); err != nil {
+		t.Fatal(err)
+	}
+	if !mr.Exists("test:obj:1") {
+		t.Errorf("key is not stored within namespace")
+	}
+
+	var obj testObject
+	if err := rc.GetObject("obj:1", &obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj.Name != "first" || obj.Count != 1 {
+		t.Errorf("GetObject() = %v", obj)
+	}
+	value, err := rc.Get("obj:1")
+	if m, ok := value.(map[string]interface{}); err != nil || !ok || m["Name"] != "first" {
+		t.Errorf("Get() = %v, %v", value, err)
+	}
+
+	if err := rc.Delete("obj:1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Get("obj:1"); err != ErrNotFound {
+		t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestRedisCache_TTL(t *testing.T) {
+	mr, rc := newTestCache(t)
+	defer mr.Close()
+
+	if err := rc.SetWithTTL("session", "data", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := rc.TTL("session"); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL() = %v, %v", ttl, err)
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if _, err := rc.Get("session"); err != ErrNotFound {
+		t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+	}
+
+	if err := rc.Set("forever", "data"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := rc.TTL("forever"); err != nil || ttl != 0 {
+		t.Errorf("TTL() = %v, %v", ttl, err)
+	}
+}
+
+func TestRedisCache_Iterate(t *testing.T) {
+	mr, rc := newTestCache(t)
+	defer mr.Close()
+	rc.SetNamespace("it")
+	_ = mr.Set("other", "skip")
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := rc.Set(key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	found := make(map[string]string)
+	rc.Iterate(func(key, val string) bool {
+		found[key] = val
+		return true
+	})
+	if len(found) != 3 || found["b"] != `"b"` {
+		t.Errorf("Iterate() = %v", found)
+	}
+}
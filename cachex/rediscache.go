@@ -1,3 +0,0 @@
-package cachex
-
-//https://github.com/go-redis/redis
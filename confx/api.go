@@ -8,15 +8,12 @@ import (
 	"github.com/BurntSushi/toml"
 )
 
+// ParseToml decodes the toml file into target, the error of the missing file is returned to the caller.
 func ParseToml(file string, target interface{}) (err error) {
-	if _, err = os.Stat(file); err == nil {
-		logx.Infof("Config file : %s Found!", file)
-		_, err = toml.DecodeFile(file, target)
-		if err != nil {
-			logx.Error(err)
-		}
-	} else {
-		logx.Errorf("Config file : %s is not found!", file)
+	if _, err = os.Stat(file); err != nil {
+		return
 	}
+	logx.Infof("Config file : %s Found!", file)
+	_, err = toml.DecodeFile(file, target)
 	return
 }
@@ -0,0 +1,335 @@
+package confx
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/fidelfly/gox/pkg/strx"
+)
+
+// Struct tags used by Loader
+const (
+	TagDefault  = "default"  // default value of the field
+	TagEnv      = "env"      // environment variable name, the prefix is not added to it
+	TagFlag     = "flag"     // command line flag name
+	TagRequired = "required" // `required:"true"` means the field must not be zero value
+)
+
+var ErrNotPointer = errors.New("config target must be a non-nil pointer to struct")
+
+// Validator is implemented by the config which has its own validation rules,
+// it's called after the required fields are checked.
+type Validator interface {
+	Validate() error
+}
+
+// Errors collects all the errors found during loading.
+type Errors []error
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, err := range es {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Loader fills the config struct in layers, the later layer overrides the former one:
+// struct tag defaults, config files, environment variables and explicit flags.
+type Loader struct {
+	files     []string
+	optional  map[string]bool
+	envPrefix string
+	env       bool
+	flagSet   *flag.FlagSet
+}
+
+type Option func(*Loader)
+
+//export
+// File adds the config files, the format is decided by the extension (.toml, .yaml, .yml or .json).
+func File(files ...string) Option {
+	return func(l *Loader) {
+		l.files = append(l.files, files...)
+	}
+}
+
+//export
+// OptionalFile adds the config files which are skipped if they don't exist.
+func OptionalFile(files ...string) Option {
+	return func(l *Loader) {
+		for _, file := range files {
+			l.files = append(l.files, file)
+			l.optional[file] = true
+		}
+	}
+}
+
+//export
+// Env enables the environment variables, the variable name of field "Log.LogLevel" is "PREFIX_LOG_LOG_LEVEL".
+func Env(prefix string) Option {
+	return func(l *Loader) {
+		l.env = true
+		l.envPrefix = prefix
+	}
+}
+
+//export
+// Flags applies the flags of fs which are set explicitly, fs must be parsed by the caller.
+func Flags(fs *flag.FlagSet) Option {
+	return func(l *Loader) {
+		l.flagSet = fs
+	}
+}
+
+//export
+func NewLoader(opts ...Option) *Loader {
+	l := &Loader{optional: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+//export
+func Load(target interface{}, opts ...Option) error {
+	return NewLoader(opts...).Load(target)
+}
+
+// Load fills target which must be a pointer to struct, all the errors are reported together.
+func (l *Loader) Load(target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrNotPointer
+	}
+
+	var errs Errors
+	fields := collectFields(rv.Elem(), "", nil)
+
+	for _, f := range fields {
+		if def, ok := f.tag.Lookup(TagDefault); ok && isZero(f.value) {
+			if err := setValue(f.value, def); err != nil {
+				errs = append(errs, fmt.Errorf("invalid default value of %s : %v", f.path, err))
+			}
+		}
+	}
+
+	for _, file := range l.files {
+		if err := decodeFile(file, target); err != nil {
+			if l.optional[file] && os.IsNotExist(err) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("load config file %s failed : %v", file, err))
+		}
+	}
+
+	if l.env {
+		for _, f := range fields {
+			name := f.envName(l.envPrefix)
+			if val, ok := os.LookupEnv(name); ok {
+				if err := setValue(f.value, val); err != nil {
+					errs = append(errs, fmt.Errorf("invalid environment variable %s : %v", name, err))
+				}
+			}
+		}
+	}
+
+	if l.flagSet != nil {
+		visited := make(map[string]*flag.Flag)
+		l.flagSet.Visit(func(fl *flag.Flag) {
+			visited[fl.Name] = fl
+		})
+		for _, f := range fields {
+			name := f.tag.Get(TagFlag)
+			if len(name) == 0 {
+				continue
+			}
+			if fl, ok := visited[name]; ok {
+				if err := setValue(f.value, fl.Value.String()); err != nil {
+					errs = append(errs, fmt.Errorf("invalid flag -%s : %v", name, err))
+				}
+			}
+		}
+	}
+
+	errs = append(errs, Validate(target)...)
+	if validator, ok := target.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Validate checks the fields tagged with `required:"true"`.
+func Validate(target interface{}) (errs Errors) {
+	rv := reflect.Indirect(reflect.ValueOf(target))
+	if rv.Kind() != reflect.Struct {
+		return Errors{ErrNotPointer}
+	}
+	for _, f := range collectFields(rv, "", nil) {
+		if required, _ := strconv.ParseBool(f.tag.Get(TagRequired)); required && isZero(f.value) {
+			errs = append(errs, fmt.Errorf("%s is required", f.path))
+		}
+	}
+	return
+}
+
+func decodeFile(file string, target interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(data, target)
+	case ".json":
+		return json.Unmarshal(data, target)
+	default:
+		_, err = toml.Decode(string(data), target)
+		return err
+	}
+}
+
+type configField struct {
+	path  string
+	names []string
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+func (cf configField) envName(prefix string) string {
+	if name := cf.tag.Get(TagEnv); len(name) > 0 {
+		return name
+	}
+	parts := make([]string, 0, len(cf.names)+1)
+	if len(prefix) > 0 {
+		parts = append(parts, prefix)
+	}
+	for _, name := range cf.names {
+		parts = append(parts, strx.UnderscoreString(name))
+	}
+	return strings.ToUpper(strings.Join(parts, "_"))
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// collectFields returns the leaf fields of the struct, nested structs are expanded.
+func collectFields(rv reflect.Value, prefix string, names []string) []configField {
+	var fields []configField
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		fv := rv.Field(i)
+		if len(sf.PkgPath) > 0 || !fv.CanSet() {
+			continue
+		}
+		path := sf.Name
+		if len(prefix) > 0 {
+			path = prefix + "." + sf.Name
+		}
+		fieldNames := append(append([]string{}, names...), sf.Name)
+		if sf.Anonymous {
+			fieldNames = names
+			path = prefix
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Struct && !reflect.PtrTo(ft).Implements(textUnmarshalerType) {
+			fields = append(fields, collectFields(fv, path, fieldNames)...)
+			continue
+		}
+		fields = append(fields, configField{path: path, names: fieldNames, value: fv, tag: sf.Tag})
+	}
+	return fields
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// nolint:gocyclo
+func setValue(v reflect.Value, s string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		var items []string
+		if len(s) > 0 {
+			items = strings.Split(s, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package confx
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testLogConfig struct {
+	LogLevel string `default:"warn" flag:"log-level"`
+	MaxSize  int    `default:"10"`
+}
+
+type testConfig struct {
+	Name    string        `required:"true"`
+	Port    int64         `default:"8080"`
+	Timeout time.Duration `default:"5s"`
+	Hosts   []string
+	Secret  string `env:"TEST_SECRET" required:"true"`
+	Log     testLogConfig
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoader_Load(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"toml": writeFile(t, dir, "config.toml", "Name = \"toml\"\n[Log]\nMaxSize = 20\n"),
+		"yaml": writeFile(t, dir, "config.yaml", "name: yaml\nlog:\n  maxsize: 20\n"),
+		"json": writeFile(t, dir, "config.json", `{"Name": "json", "Log": {"MaxSize": 20}}`),
+	}
+
+	_ = os.Setenv("APP_HOSTS", "a.com, b.com")
+	_ = os.Setenv("APP_LOG_LOG_LEVEL", "info")
+	_ = os.Setenv("TEST_SECRET", "secret")
+	defer os.Unsetenv("APP_HOSTS")
+	defer os.Unsetenv("APP_LOG_LOG_LEVEL")
+	defer os.Unsetenv("TEST_SECRET")
+
+	for format, file := range files {
+		t.Run(format, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.String("log-level", "error", "")
+			if err := fs.Parse([]string{"-log-level", "debug"}); err != nil {
+				t.Fatal(err)
+			}
+
+			var config testConfig
+			if err := Load(&config, File(file), Env("APP"), Flags(fs)); err != nil {
+				t.Fatal(err)
+			}
+			if config.Name != format || config.Port != 8080 || config.Timeout != 5*time.Second {
+				t.Errorf("Load() = %+v", config)
+			}
+			if config.Log.MaxSize != 20 || config.Log.LogLevel != "debug" {
+				t.Errorf("Load() Log = %+v", config.Log)
+			}
+			if len(config.Hosts) != 2 || config.Hosts[1] != "b.com" || config.Secret != "secret" {
+				t.Errorf("Load() env = %v, %s", config.Hosts, config.Secret)
+			}
+		})
+	}
+}
+
+func TestLoader_Errors(t *testing.T) {
+	_ = os.Setenv("BAD_PORT", "not a number")
+	defer os.Unsetenv("BAD_PORT")
+
+	var config testConfig
+	err := Load(&config, File("missing.toml"), OptionalFile("missing.yaml"), Env("BAD"))
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// missing.toml, BAD_PORT, Name and Secret
+	if len(errs) != 4 {
+		t.Errorf("Load() errors = %v", errs)
+	}
+}
@@ -0,0 +1,152 @@
+package confx
+
+import (
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fidelfly/gox/logx"
+	"github.com/fidelfly/gox/pkg/filex"
+)
+
+// ChangeHandler is notified with the pointers to the previous and the reloaded config.
+type ChangeHandler func(oldCfg, newCfg interface{})
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	hash    string
+}
+
+// Watcher polls the config files and reloads the config when their content is changed.
+// The config is decoded into a fresh struct and swapped in only if it passes the validation.
+type Watcher struct {
+	loader   *Loader
+	typ      reflect.Type
+	current  interface{}
+	states   map[string]fileState
+	handlers []ChangeHandler
+	lock     sync.RWMutex
+	scanLock sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+//export
+// NewWatcher loads the config into target, target is the current config until the first reload.
+func NewWatcher(target interface{}, opts ...Option) (*Watcher, error) {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, ErrNotPointer
+	}
+	w := &Watcher{
+		loader:  NewLoader(opts...),
+		typ:     rv.Elem().Type(),
+		current: target,
+		states:  make(map[string]fileState),
+		stop:    make(chan struct{}),
+	}
+	w.scan()
+	if err := w.loader.Load(target); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Watcher) Subscribe(handlers ...ChangeHandler) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.handlers = append(w.handlers, handlers...)
+}
+
+// Current returns the pointer to the config which is loaded most recently.
+func (w *Watcher) Current() interface{} {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.current
+}
+
+// Start checks the files every interval in background until Stop is called.
+func (w *Watcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := w.Check(); err != nil {
+					logx.Errorf("Config is not reloaded : %v", err)
+				}
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
+
+// Check reloads the config if any file is changed, the broken content is not retried until it's changed again.
+func (w *Watcher) Check() (bool, error) {
+	w.scanLock.Lock()
+	defer w.scanLock.Unlock()
+	if !w.scan() {
+		return false, nil
+	}
+	return true, w.reload()
+}
+
+// scan updates the states of the files and reports whether the content is changed.
+func (w *Watcher) scan() (changed bool) {
+	for _, file := range w.loader.files {
+		var state fileState
+		if info, err := os.Stat(file); err == nil {
+			state = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		old := w.states[file]
+		if state.modTime.Equal(old.modTime) && state.size == old.size {
+			continue
+		}
+		if !state.modTime.IsZero() {
+			state.hash = filex.CalculateFileMd5(file)
+		}
+		if state.hash != old.hash {
+			changed = true
+		}
+		w.states[file] = state
+	}
+	return
+}
+
+func (w *Watcher) reload() error {
+	fresh := reflect.New(w.typ).Interface()
+	if err := w.loader.Load(fresh); err != nil {
+		return err
+	}
+
+	w.lock.Lock()
+	old := w.current
+	w.current = fresh
+	handlers := w.handlers
+	w.lock.Unlock()
+
+	logx.Info("Config is reloaded")
+	for _, handler := range handlers {
+		notifyChange(handler, old, fresh)
+	}
+	return nil
+}
+
+func notifyChange(handler ChangeHandler, oldCfg, newCfg interface{}) {
+	defer func() {
+		if err := recover(); err != nil {
+			logx.Errorf("Panic found in config change handler : %v", err)
+		}
+	}()
+	handler(oldCfg, newCfg)
+}
@@ -0,0 +1,55 @@
+package confx
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+type watchConfig struct {
+	Name  string `required:"true"`
+	Level string `default:"warn"`
+}
+
+func TestWatcher_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := writeFile(t, dir, "config.toml", "Name = \"first\"\n")
+
+	config := &watchConfig{}
+	w, err := NewWatcher(config, File(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notified [][2]*watchConfig
+	w.Subscribe(func(oldCfg, newCfg interface{}) {
+		notified = append(notified, [2]*watchConfig{oldCfg.(*watchConfig), newCfg.(*watchConfig)})
+	})
+
+	if changed, err := w.Check(); changed || err != nil {
+		t.Errorf("Check() = %v, %v without change", changed, err)
+	}
+
+	writeFile(t, dir, "config.toml", "Name = \"second\"\nLevel = \"debug\"\n")
+	if changed, err := w.Check(); !changed || err != nil {
+		t.Fatalf("Check() = %v, %v", changed, err)
+	}
+	if len(notified) != 1 || notified[0][0] != config || notified[0][1].Name != "second" || notified[0][1].Level != "debug" {
+		t.Errorf("notified = %v", notified)
+	}
+
+	// invalid config is not swapped in
+	writeFile(t, dir, "config.toml", "Level = \"info\"\n")
+	if changed, err := w.Check(); !changed || err == nil {
+		t.Errorf("Check() = %v, %v, want validation error", changed, err)
+	}
+	if current := w.Current().(*watchConfig); current.Name != "second" || len(notified) != 1 {
+		t.Errorf("Current() = %v after invalid change", current)
+	}
+	if config.Name != "first" {
+		t.Errorf("initial config is modified : %v", config)
+	}
+}
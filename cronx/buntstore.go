@@ -0,0 +1,157 @@
+package cronx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/buntdb"
+)
+
+const (
+	buntJobPrefix = "cronx:job:"
+	buntRunPrefix = "cronx:run:"
+)
+
+// BuntStore persists the jobs into buntdb.
+type BuntStore struct {
+	db *buntdb.DB
+}
+
+//export
+func NewBuntStore(filename string) (*BuntStore, error) {
+	db, err := buntdb.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &BuntStore{db: db}, nil
+}
+
+//export
+func NewBuntStoreWithDB(db *buntdb.DB) *BuntStore {
+	return &BuntStore{db: db}
+}
+
+func (bs *BuntStore) GetDB() *buntdb.DB {
+	return bs.db
+}
+
+func (bs *BuntStore) Close() error {
+	return bs.db.Close()
+}
+
+func runKey(run RunRecord) string {
+	// the zero padded timestamp keeps the records of a job in order
+	return fmt.Sprintf("%s%s:%020d", buntRunPrefix, run.Key, run.Start.UnixNano())
+}
+
+func (bs *BuntStore) SaveJob(record JobRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return bs.db.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(buntJobPrefix+record.Key, string(data), nil)
+		return err
+	})
+}
+
+func (bs *BuntStore) DeleteJob(key string) error {
+	err := bs.db.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Delete(buntJobPrefix + key)
+		return err
+	})
+	if err == buntdb.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (bs *BuntStore) ListJobs() ([]JobRecord, error) {
+	var records []JobRecord
+	var decodeErr error
+	err := bs.db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys(buntJobPrefix+"*", func(key, val string) bool {
+			var record JobRecord
+			if decodeErr = json.Unmarshal([]byte(val), &record); decodeErr != nil {
+				decodeErr = fmt.Errorf("decode job %s failed : %v", key, decodeErr)
+				return false
+			}
+			records = append(records, record)
+			return true
+		})
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (bs *BuntStore) AddRun(run RunRecord) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	return bs.db.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(runKey(run), string(data), nil)
+		return err
+	})
+}
+
+func (bs *BuntStore) ListRuns(q RunQuery) ([]RunRecord, error) {
+	pattern := buntRunPrefix + "*"
+	if len(q.Key) > 0 {
+		pattern = buntRunPrefix + q.Key + ":*"
+	}
+	var runs []RunRecord
+	var decodeErr error
+	err := bs.db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys(pattern, func(key, val string) bool {
+			var run RunRecord
+			if decodeErr = json.Unmarshal([]byte(val), &run); decodeErr != nil {
+				decodeErr = fmt.Errorf("decode run %s failed : %v", key, decodeErr)
+				return false
+			}
+			runs = append(runs, run)
+			return true
+		})
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return filterRuns(runs, q), nil
+}
+
+func (bs *BuntStore) PruneRuns(key string, keep int) error {
+	return bs.db.Update(func(tx *buntdb.Tx) error {
+		var keys []string
+		err := tx.AscendKeys(buntRunPrefix+key+":*", func(k, v string) bool {
+			if isRunKey(k, key) {
+				keys = append(keys, k)
+			}
+			return true
+		})
+		if err != nil || len(keys) <= keep {
+			return err
+		}
+		for _, k := range keys[:len(keys)-keep] {
+			if _, err = tx.Delete(k); err != nil && err != buntdb.ErrNotFound {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// isRunKey checks the key exactly, the pattern of one job may match the runs of another job
+// whose key has the same prefix or contains the wildcard characters.
+func isRunKey(k string, jobKey string) bool {
+	prefix := buntRunPrefix + jobKey + ":"
+	return len(k) == len(prefix)+20 && strings.HasPrefix(k, prefix)
+}
@@ -0,0 +1,134 @@
+package cronx
+
+import (
+	"errors"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrEntryNotFound = errors.New("entry is not found")
+	ErrTimerJob      = errors.New("timer job can't be paused, triggered or rescheduled")
+)
+
+func (cx *Cronx) cronJob(id int) (*cronJob, error) {
+	entry := cx.Entry(id)
+	if !entry.Valid() {
+		return nil, ErrEntryNotFound
+	}
+	cj, ok := entry.Job.(*cronJob)
+	if !ok {
+		return nil, ErrEntryNotFound
+	}
+	return cj, nil
+}
+
+func (cx *Cronx) setPaused(id int, paused bool) error {
+	cj, err := cx.cronJob(id)
+	if err != nil {
+		return err
+	}
+	if cj.timer {
+		return ErrTimerJob
+	}
+	cj.lock.Lock()
+	cj.paused = paused
+	cj.lock.Unlock()
+	cx.saveRecord(cj, time.Time{})
+	return nil
+}
+
+// Pause keeps the entry scheduled but skips its runs until it's resumed,
+// the paused state of the named job is persisted. The timer job fires only once and is removed after
+// that, so it can't be paused and ErrTimerJob is returned for it.
+func (cx *Cronx) Pause(id int) error {
+	return cx.setPaused(id, true)
+}
+
+func (cx *Cronx) Resume(id int) error {
+	return cx.setPaused(id, false)
+}
+
+// Trigger runs the job of the entry immediately in background, the paused entry is also executed.
+// The run is reported like the scheduled one. The timer job is removed after its run, so ErrTimerJob is returned for it.
+func (cx *Cronx) Trigger(id int) error {
+	cj, err := cx.cronJob(id)
+	if err != nil {
+		return err
+	}
+	if cj.timer {
+		return ErrTimerJob
+	}
+	go func() {
+		_ = cj.run()
+	}()
+	return nil
+}
+
+// parseSpec parses the spec with the parser configured by WithCronOption.
+func (cx *Cronx) parseSpec(spec string) (cron.Schedule, error) {
+	probe := cron.New(cx.cronOptions...)
+	id, err := probe.AddFunc(spec, func() {})
+	if err != nil {
+		return nil, err
+	}
+	return probe.Entry(id).Schedule, nil
+}
+
+// Reschedule changes the spec of the entry. The entry is scheduled again, so a new id is returned,
+// while the job key is kept. The old entry is removed before the new one is added, so the job doesn't run twice.
+func (cx *Cronx) Reschedule(id int, spec string) (int, error) {
+	cj, err := cx.cronJob(id)
+	if err != nil {
+		return 0, err
+	}
+	if cj.timer {
+		return 0, ErrTimerJob
+	}
+	schedule, err := cx.parseSpec(spec)
+	if err != nil {
+		return 0, err
+	}
+
+	cx.lock.Lock()
+	cx.inCron.Remove(cron.EntryID(id))
+	newID := cx.inCron.Schedule(schedule, cj)
+	cx.keyMap[cj.key] = int(newID)
+	cx.lock.Unlock()
+
+	cj.lock.Lock()
+	if cj.record != nil {
+		cj.record.Spec = spec
+	}
+	cj.lock.Unlock()
+	cx.saveRecord(cj, time.Time{})
+	return int(newID), nil
+}
+
+// EntryByKey returns the entry of the job key, the returned entry is invalid if it's not found.
+func (cx *Cronx) EntryByKey(key string) Entry {
+	if id, ok := cx.entryID(key); ok {
+		return cx.Entry(id)
+	}
+	return Entry{}
+}
+
+// FindEntries returns the entries whose metadata contains all the pairs of md.
+func (cx *Cronx) FindEntries(md map[string]string) []Entry {
+	var entries []Entry
+	for _, entry := range cx.Entries() {
+		meta := entry.Meta()
+		matched := true
+		for k, v := range md {
+			if mv, ok := meta.Get(k); !ok || mv != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,129 @@
+package cronx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestCronx_Control(t *testing.T) {
+	store := NewMemoryStore()
+	finished := make(chan Event, 10)
+	cx := New(WithStore(store), WithListener(func(event Event) {
+		if event.Type != EventStarted {
+			finished <- event
+		}
+	}))
+	cx.RegisterFunc("report", func(ctx context.Context) error { return nil })
+	id, err := cx.AddNamedJob("@daily", "report", map[string]string{"team": "ops"}, JobKeyMeta("daily"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = cx.Pause(id); err != nil {
+		t.Fatal(err)
+	}
+	if !cx.Entry(id).Paused() {
+		t.Error("Paused() after Pause() = false")
+	}
+	if records, _ := store.ListJobs(); !records[0].Paused {
+		t.Error("pause is not saved to the store")
+	}
+
+	// the paused entry is still able to be triggered
+	if err = cx.Trigger(id); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-finished:
+		if event.Type != EventSucceeded || event.Key != "daily" {
+			t.Errorf("event = %+v, want the succeeded event of daily", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("job is not triggered")
+	}
+	if runs, _ := cx.History(RunQuery{Key: "daily"}); len(runs) != 1 {
+		t.Errorf("History() returns %d runs, want 1", len(runs))
+	}
+
+	if err = cx.Resume(id); err != nil {
+		t.Fatal(err)
+	}
+	if cx.Entry(id).Paused() {
+		t.Error("Paused() after Resume() = true")
+	}
+
+	newID, err := cx.Reschedule(id, "@hourly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cx.Entry(id).Valid() {
+		t.Error("the rescheduled entry is still valid")
+	}
+	if keyID := int(cx.EntryByKey("daily").ID); keyID != newID {
+		t.Errorf("EntryByKey() id = %d, want %d", keyID, newID)
+	}
+	if records, _ := store.ListJobs(); records[0].Spec != "@hourly" || records[0].Paused {
+		t.Errorf("saved job = %+v, want the resumed job of @hourly", records[0])
+	}
+	if _, err = cx.Reschedule(newID, "invalid"); err == nil {
+		t.Error("Reschedule() to the invalid spec returns nil error")
+	}
+	if !cx.Entry(newID).Valid() {
+		t.Error("the entry is removed by the failed rescheduling")
+	}
+
+	timerID := cx.AddTimerFunc(time.Now().Add(time.Hour), func(ctx context.Context) error { return nil })
+	if _, err = cx.Reschedule(timerID, "@hourly"); err != ErrTimerJob {
+		t.Errorf("Reschedule() of the timer job = %v, want %v", err, ErrTimerJob)
+	}
+	if err = cx.Trigger(timerID); err != ErrTimerJob {
+		t.Errorf("Trigger() of the timer job = %v, want %v", err, ErrTimerJob)
+	}
+	// the paused timer job would miss its only run and never be removed
+	if err = cx.Pause(timerID); err != ErrTimerJob {
+		t.Errorf("Pause() of the timer job = %v, want %v", err, ErrTimerJob)
+	}
+	if cx.Entry(timerID).Paused() {
+		t.Error("the timer job is paused")
+	}
+	if !cx.Entry(timerID).Valid() {
+		t.Error("the timer job is removed")
+	}
+
+	if entries := cx.FindEntries(map[string]string{"team": "ops"}); len(entries) != 1 || entries[0].Key() != "daily" {
+		t.Errorf("FindEntries() = %v, want the daily entry", entries)
+	}
+	if err = cx.Pause(1000); err != ErrEntryNotFound {
+		t.Errorf("Pause() of the unknown entry = %v, want %v", err, ErrEntryNotFound)
+	}
+}
+
+func TestCronx_RescheduleParser(t *testing.T) {
+	cx := New(WithCronOption(cron.WithSeconds()))
+	id, err := cx.AddFunc("0 0 * * * *", func(ctx context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the spec is parsed by the parser of the scheduler before the entry is replaced
+	if _, err = cx.Reschedule(id, "@hourly"); err != nil {
+		t.Fatal(err)
+	}
+	id = int(cx.Entries()[0].ID)
+	if _, err = cx.Reschedule(id, "0 * * * *"); err == nil {
+		t.Error("Reschedule() to the spec without seconds returns nil error")
+	}
+	newID, err := cx.Reschedule(id, "30 0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := cx.Entries()
+	if len(entries) != 1 || int(entries[0].ID) != newID {
+		t.Errorf("Entries() = %v, want the rescheduled entry only", entries)
+	}
+	if next := entries[0].Schedule.Next(time.Now()); next.Second() != 30 {
+		t.Errorf("next run at %v, want the 30th second", next)
+	}
+}
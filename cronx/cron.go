@@ -2,17 +2,35 @@ package cronx
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
 
+	"github.com/fidelfly/gox/logx"
 	"github.com/fidelfly/gox/pkg/randx"
 )
 
+var (
+	ErrNoStore          = errors.New("job store is not configured")
+	ErrJobNotRegistered = errors.New("job is not registered")
+	ErrDuplicateJobKey  = errors.New("job key is already scheduled")
+)
+
+const defaultHistoryLimit = 100
+
 type Cronx struct {
-	inCron      *cron.Cron
-	middlewares []JobMiddleware
-	keyMap      map[string]int
+	inCron       *cron.Cron
+	cronOptions  []cron.Option
+	middlewares  []JobMiddleware
+	keyMap       map[string]int
+	lock         sync.RWMutex
+	store        JobStore
+	registry     map[string]Job
+	historyLimit int
+	listeners    []Listener
+	listenerLock sync.RWMutex
 }
 
 type Job interface {
@@ -38,6 +56,30 @@ func (e Entry) Meta() *Metadata {
 	}
 	return NewMetadata()
 }
+
+// LastResult returns the outcome of the latest run, false is returned if the job never runs.
+func (e Entry) LastResult() (RunResult, bool) {
+	if cj, ok := e.Job.(*cronJob); ok {
+		return cj.lastResult()
+	}
+	return RunResult{}, false
+}
+
+// Name returns the registered name of the named job.
+func (e Entry) Name() string {
+	if cj, ok := e.Job.(*cronJob); ok {
+		return cj.name()
+	}
+	return ""
+}
+
+func (e Entry) Paused() bool {
+	if cj, ok := e.Job.(*cronJob); ok {
+		return cj.isPaused()
+	}
+	return false
+}
+
 func (e Entry) Key() string {
 	md := e.Meta()
 	if md != nil {
@@ -57,6 +99,15 @@ func (md *Metadata) Get(key string) (string, bool) {
 	return "", false
 }
 
+// Map returns a copy of the metadata.
+func (md *Metadata) Map() map[string]string {
+	mp := make(map[string]string, len(md.meta))
+	for k, v := range md.meta {
+		mp[k] = v
+	}
+	return mp
+}
+
 //func (md *Metadata) GetJobKey() string {
 //	key, _ := md.Get(metaJobKey)
 //	return key
@@ -75,7 +126,11 @@ func NewMetadata(datas ...map[string]string) *Metadata {
 }
 
 func New(opts ...Option) *Cronx {
-	cx := &Cronx{keyMap: make(map[string]int)}
+	cx := &Cronx{
+		keyMap:       make(map[string]int),
+		registry:     make(map[string]Job),
+		historyLimit: defaultHistoryLimit,
+	}
 	for _, opt := range opts {
 		opt(cx)
 	}
@@ -86,6 +141,32 @@ func New(opts ...Option) *Cronx {
 	return cx
 }
 
+// Register makes the job available to the named jobs, it must be called before Start
+// so that the persisted jobs using the name can be restored.
+func (cx *Cronx) Register(name string, job Job) {
+	cx.lock.Lock()
+	defer cx.lock.Unlock()
+	cx.registry[name] = job
+}
+
+func (cx *Cronx) RegisterFunc(name string, cmd func(context.Context) error) {
+	cx.Register(name, FuncJob(cmd))
+}
+
+func (cx *Cronx) registered(name string) (Job, bool) {
+	cx.lock.RLock()
+	defer cx.lock.RUnlock()
+	job, ok := cx.registry[name]
+	return job, ok
+}
+
+func (cx *Cronx) wrapJob(job Job) Job {
+	if len(cx.middlewares) > 0 {
+		job = AttachMiddleware(job, cx.middlewares...)
+	}
+	return job
+}
+
 func (cx *Cronx) AddFunc(spec string, cmd func(context.Context) error, mds ...map[string]string) (int, error) {
 	return cx.AddJob(spec, FuncJob(cmd), mds...)
 }
@@ -93,22 +174,37 @@ func (cx *Cronx) AddFunc(spec string, cmd func(context.Context) error, mds ...ma
 const uuidSeed = "job.uuid"
 
 func (cx *Cronx) AddJob(spec string, job Job, mds ...map[string]string) (int, error) {
-	if len(cx.middlewares) > 0 {
-		job = AttachMiddleware(job, cx.middlewares...)
+	return cx.scheduleSpec(spec, cx.newCronJob(cx.wrapJob(job), mds...))
+}
+
+// AddNamedJob schedules the registered job, the job is persisted if the store is configured.
+func (cx *Cronx) AddNamedJob(spec string, name string, mds ...map[string]string) (int, error) {
+	job, ok := cx.registered(name)
+	if !ok {
+		return 0, ErrJobNotRegistered
 	}
-	jobKey := randx.GenUUID(uuidSeed)
-	id, err := cx.inCron.AddJob(spec, newCronJob(jobKey, job, mds...))
-	cx.keyMap[jobKey] = int(id)
-	return int(id), err
+	cj := cx.newCronJob(cx.wrapJob(job), append(mds, jobNameMeta(name))...)
+	cj.record = &JobRecord{Key: cj.key, Name: name, Spec: spec, Metadata: userMetadata(cj.md)}
+	return cx.scheduleSpec(spec, cj)
 }
 
-func (cx *Cronx) RunJob(job Job, mds ...map[string]string) error {
-	if len(cx.middlewares) > 0 {
-		job = AttachMiddleware(job, cx.middlewares...)
+// AddNamedTimer schedules the registered job to run once at t, the job is persisted if the store is configured.
+func (cx *Cronx) AddNamedTimer(t time.Time, name string, mds ...map[string]string) (int, error) {
+	job, ok := cx.registered(name)
+	if !ok {
+		return 0, ErrJobNotRegistered
 	}
+	cj := cx.newCronJob(cx.removeTimerJob(cx.wrapJob(job)), append(mds, jobNameMeta(name))...)
+	cj.record = &JobRecord{Key: cj.key, Name: name, Timer: t, Metadata: userMetadata(cj.md)}
+	return cx.scheduleTimer(NewTimerSchedule(t), cj)
+}
+
+func (cx *Cronx) RunJob(job Job, mds ...map[string]string) error {
 	jobKey := randx.GenUUID(uuidSeed)
-	runJob := newCronJob(jobKey, job, mds...)
-	return runJob.execute()
+	runJob := newCronJob(jobKey, cx.wrapJob(job), mds...)
+	runJob.cx = cx
+	runJob.adhoc = true
+	return runJob.run()
 }
 
 func (cx *Cronx) removeTimerJob(job Job) Job {
@@ -116,7 +212,7 @@ func (cx *Cronx) removeTimerJob(job Job) Job {
 		md := GetMetadata(ctx)
 		err = job.Run(ctx)
 		if jobKey := GetJobKey(md); len(jobKey) > 0 {
-			if id, ok := cx.keyMap[jobKey]; ok {
+			if id, ok := cx.entryID(jobKey); ok {
 				go cx.Remove(id)
 			}
 		}
@@ -129,30 +225,187 @@ func (cx *Cronx) AddTimerFunc(t time.Time, cmd func(context.Context) error, mds
 }
 
 func (cx *Cronx) AddTimerJob(t time.Time, job Job, mds ...map[string]string) int {
-	if len(cx.middlewares) > 0 {
-		job = cx.removeTimerJob(AttachMiddleware(job, cx.middlewares...))
+	cj := cx.newCronJob(cx.removeTimerJob(cx.wrapJob(job)), mds...)
+	id, _ := cx.scheduleTimer(NewTimerSchedule(t), cj)
+	return id
+}
+
+func (cx *Cronx) scheduleSpec(spec string, cj *cronJob) (int, error) {
+	cx.lock.Lock()
+	if _, ok := cx.keyMap[cj.key]; ok {
+		cx.lock.Unlock()
+		return 0, ErrDuplicateJobKey
 	}
-	jobKey := randx.GenUUID(uuidSeed)
-	schedule := NewTimerSchedule(t)
-	id := cx.inCron.Schedule(schedule, newCronJob(jobKey, job, mds...))
-	cx.keyMap[jobKey] = int(id)
-	return int(id)
+	id, err := cx.inCron.AddJob(spec, cj)
+	if err != nil {
+		cx.lock.Unlock()
+		return 0, err
+	}
+	cx.keyMap[cj.key] = int(id)
+	cx.lock.Unlock()
+	cx.saveRecord(cj, time.Time{})
+	return int(id), nil
+}
+
+func (cx *Cronx) scheduleTimer(schedule cron.Schedule, cj *cronJob) (int, error) {
+	cj.timer = true
+	cx.lock.Lock()
+	if _, ok := cx.keyMap[cj.key]; ok {
+		cx.lock.Unlock()
+		return 0, ErrDuplicateJobKey
+	}
+	id := cx.inCron.Schedule(schedule, cj)
+	cx.keyMap[cj.key] = int(id)
+	cx.lock.Unlock()
+	cx.saveRecord(cj, time.Time{})
+	return int(id), nil
+}
+
+func (cx *Cronx) entryID(jobKey string) (int, bool) {
+	cx.lock.RLock()
+	defer cx.lock.RUnlock()
+	id, ok := cx.keyMap[jobKey]
+	return id, ok
 }
 
 func (cx *Cronx) Remove(id int) {
 	cx.inCron.Remove(cron.EntryID(id))
+	cx.lock.Lock()
+	defer cx.lock.Unlock()
 	for k, v := range cx.keyMap {
 		if v == id {
 			delete(cx.keyMap, k)
+			if cx.store != nil {
+				if err := cx.store.DeleteJob(k); err != nil {
+					logx.Warnf("delete job %s from store failed : %v", k, err)
+				}
+			}
 			return
 		}
 	}
 }
 
+// Start restores the persisted jobs and then starts the scheduler.
 func (cx *Cronx) Start() {
+	if cx.store != nil {
+		cx.restore()
+	}
 	cx.inCron.Start()
 }
 
+// restore schedules the persisted jobs which are not scheduled yet,
+// a timer job missed while the scheduler is down runs once immediately.
+func (cx *Cronx) restore() {
+	records, err := cx.store.ListJobs()
+	if err != nil {
+		logx.Errorf("load jobs from store failed : %v", err)
+		return
+	}
+	now := time.Now()
+	for _, record := range records {
+		if _, ok := cx.entryID(record.Key); ok {
+			continue
+		}
+		job, ok := cx.registered(record.Name)
+		if !ok {
+			logx.Warnf("job %s(%s) is not restored : %v", record.Name, record.Key, ErrJobNotRegistered)
+			continue
+		}
+		rec := record
+		mds := []map[string]string{record.Metadata, JobKeyMeta(record.Key), jobNameMeta(record.Name)}
+		if record.IsTimer() {
+			// the timer job can't be paused, it runs once and is removed
+			cj := cx.newCronJob(cx.removeTimerJob(cx.wrapJob(job)), mds...)
+			rec.Paused = false
+			cj.record = &rec
+			var schedule cron.Schedule = NewTimerSchedule(record.Timer)
+			if !record.Timer.After(now) {
+				schedule = &onceSchedule{}
+			}
+			_, err = cx.scheduleTimer(schedule, cj)
+		} else {
+			cj := cx.newCronJob(cx.wrapJob(job), mds...)
+			cj.record = &rec
+			cj.paused = rec.Paused
+			_, err = cx.scheduleSpec(record.Spec, cj)
+		}
+		if err != nil {
+			logx.Warnf("job %s(%s) is not restored : %v", record.Name, record.Key, err)
+		}
+	}
+}
+
+// saveRecord persists the named job with its latest schedule.
+func (cx *Cronx) saveRecord(cj *cronJob, prev time.Time) {
+	if cx.store == nil {
+		return
+	}
+	id, ok := cx.entryID(cj.key)
+	if !ok {
+		// the job is removed already
+		return
+	}
+	cj.lock.Lock()
+	if cj.record == nil {
+		cj.lock.Unlock()
+		return
+	}
+	if !prev.IsZero() {
+		cj.record.Prev = prev
+	}
+	cj.record.Paused = cj.paused
+	record := *cj.record
+	cj.lock.Unlock()
+	if record.IsTimer() {
+		record.Next = record.Timer
+	} else if entry := cx.Entry(id); entry.Valid() {
+		record.Next = entry.Schedule.Next(time.Now())
+	}
+	if err := cx.store.SaveJob(record); err != nil {
+		logx.Warnf("save job %s to store failed : %v", record.Key, err)
+	}
+}
+
+// jobDone records the run history of the scheduled job.
+func (cx *Cronx) jobDone(cj *cronJob, start, end time.Time, err error) {
+	if cx.store == nil || cj.adhoc {
+		return
+	}
+	run := RunRecord{Key: cj.key, Name: cj.name(), Start: start, End: end, Duration: end.Sub(start)}
+	if err != nil {
+		run.Error = err.Error()
+		run.Skipped = IsSkipped(err)
+	}
+	if e := cx.store.AddRun(run); e != nil {
+		logx.Warnf("save run history of job %s failed : %v", cj.key, e)
+	}
+	if cx.historyLimit > 0 {
+		if e := cx.store.PruneRuns(cj.key, cx.historyLimit); e != nil {
+			logx.Warnf("prune run history of job %s failed : %v", cj.key, e)
+		}
+	}
+	// the timer job is removed after it's done
+	if !cj.timer {
+		cx.saveRecord(cj, start)
+	}
+}
+
+// History returns the run history matched by the query, the latest run comes first.
+func (cx *Cronx) History(q RunQuery) ([]RunRecord, error) {
+	if cx.store == nil {
+		return nil, ErrNoStore
+	}
+	return cx.store.ListRuns(q)
+}
+
+// JobRecords returns the persisted named jobs.
+func (cx *Cronx) JobRecords() ([]JobRecord, error) {
+	if cx.store == nil {
+		return nil, ErrNoStore
+	}
+	return cx.store.ListJobs()
+}
+
 func (cx *Cronx) Stop() context.Context {
 	return cx.inCron.Stop()
 }
@@ -178,14 +431,78 @@ func AttachMiddleware(job Job, ms ...JobMiddleware) Job {
 }
 
 type cronJob struct {
-	job Job
-	md  *Metadata
+	key    string
+	job    Job
+	md     *Metadata
+	cx     *Cronx
+	record *JobRecord // nil if the job is not named
+	adhoc  bool       // run by RunJob, the history is not recorded
+	timer  bool
+
+	paused bool
+	last   RunResult
+	lock   sync.RWMutex
+}
+
+// RunResult is the outcome of the latest run of the job.
+type RunResult struct {
+	Start    time.Time
+	Duration time.Duration
+	Error    error
+}
+
+func (rr RunResult) Skipped() bool {
+	return IsSkipped(rr.Error)
+}
+
+func (cj *cronJob) name() string {
+	cj.lock.RLock()
+	defer cj.lock.RUnlock()
+	if cj.record != nil {
+		return cj.record.Name
+	}
+	return ""
+}
+
+func (cj *cronJob) isPaused() bool {
+	cj.lock.RLock()
+	defer cj.lock.RUnlock()
+	return cj.paused
+}
+
+func (cj *cronJob) lastResult() (RunResult, bool) {
+	cj.lock.RLock()
+	defer cj.lock.RUnlock()
+	return cj.last, !cj.last.Start.IsZero()
 }
 
 type ctxMetadataKey struct{}
 
 func (cj *cronJob) Run() {
-	_ = cj.execute()
+	if cj.isPaused() {
+		return
+	}
+	// the error is reported through the events and the run history
+	_ = cj.run()
+}
+
+func (cj *cronJob) run() error {
+	start := time.Now()
+	if cj.cx != nil {
+		cj.cx.emit(cj, EventStarted, start, 0, nil)
+	}
+	err := cj.execute()
+	end := time.Now()
+
+	cj.lock.Lock()
+	cj.last = RunResult{Start: start, Duration: end.Sub(start), Error: err}
+	cj.lock.Unlock()
+
+	if cj.cx != nil {
+		cj.cx.jobDone(cj, start, end, err)
+		cj.cx.emit(cj, resultEvent(err), start, end.Sub(start), err)
+	}
+	return err
 }
 
 func (cj *cronJob) execute() error {
@@ -193,12 +510,44 @@ func (cj *cronJob) execute() error {
 	return cj.job.Run(ctx)
 }
 
-const metaJobKey = "job.meta.key"
+const (
+	metaJobKey  = "job.meta.key"
+	metaJobName = "job.meta.name"
+)
+
+func jobNameMeta(name string) map[string]string {
+	return map[string]string{metaJobName: name}
+}
+
+// JobKeyMeta returns the metadata which assigns the key to the job instead of a generated one,
+// so that the job added again with the same key is not duplicated with the restored one.
+func JobKeyMeta(key string) map[string]string {
+	return map[string]string{metaJobKey: key}
+}
+
+func userMetadata(md *Metadata) map[string]string {
+	mp := md.Map()
+	delete(mp, metaJobKey)
+	delete(mp, metaJobName)
+	return mp
+}
+
+func (cx *Cronx) newCronJob(job Job, mds ...map[string]string) *cronJob {
+	jobMD := NewMetadata(mds...)
+	jobKey, ok := jobMD.Get(metaJobKey)
+	if !ok || len(jobKey) == 0 {
+		jobKey = randx.GenUUID(uuidSeed)
+	}
+	cj := newCronJob(jobKey, job, jobMD.meta)
+	cj.cx = cx
+	return cj
+}
 
 func newCronJob(jobKey string, job Job, mds ...map[string]string) *cronJob {
 	jobMD := NewMetadata(mds...)
 	jobMD.meta[metaJobKey] = jobKey
 	return &cronJob{
+		key: jobKey,
 		job: job,
 		md:  jobMD,
 	}
@@ -214,10 +563,22 @@ func GetMetadata(ctx context.Context) *Metadata {
 }
 
 func GetJobKey(md *Metadata) string {
+	if md == nil {
+		return ""
+	}
 	key, _ := md.Get(metaJobKey)
 	return key
 }
 
+// GetJobName returns the registered name of the job added by AddNamedJob or AddNamedTimer.
+func GetJobName(md *Metadata) string {
+	if md == nil {
+		return ""
+	}
+	name, _ := md.Get(metaJobName)
+	return name
+}
+
 type TimerSchedule struct {
 	t time.Time
 }
@@ -232,3 +593,16 @@ func (ts TimerSchedule) Next(now time.Time) time.Time {
 func NewTimerSchedule(t time.Time) *TimerSchedule {
 	return &TimerSchedule{t: t}
 }
+
+// onceSchedule runs the job as soon as the scheduler starts and never again.
+type onceSchedule struct {
+	scheduled bool
+}
+
+func (s *onceSchedule) Next(now time.Time) time.Time {
+	if s.scheduled {
+		return time.Time{}
+	}
+	s.scheduled = true
+	return now
+}
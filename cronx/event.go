@@ -0,0 +1,90 @@
+package cronx
+
+import (
+	"time"
+
+	"github.com/fidelfly/gox/logx"
+	"github.com/fidelfly/gox/pubsubx"
+)
+
+type EventType string
+
+// Types of the run event
+const (
+	EventStarted   EventType = "started"
+	EventSucceeded EventType = "succeeded"
+	EventFailed    EventType = "failed"
+	EventSkipped   EventType = "skipped"
+)
+
+// Event reports the progress of a job run, Duration and Error are only set for the finished run.
+type Event struct {
+	Type     EventType         `json:"type"`
+	Key      string            `json:"key"`
+	Name     string            `json:"name,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Start    time.Time         `json:"start"`
+	Duration time.Duration     `json:"duration,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// Listener receives the run events, it's called in the goroutine running the job,
+// so it should return quickly.
+type Listener func(event Event)
+
+func resultEvent(err error) EventType {
+	switch {
+	case err == nil:
+		return EventSucceeded
+	case IsSkipped(err):
+		return EventSkipped
+	default:
+		return EventFailed
+	}
+}
+
+//export
+// PublishTo returns the listener which publishes the events to the topic.
+func PublishTo(pxs *pubsubx.PubXSub, topic string) Listener {
+	return func(event Event) {
+		pxs.Publish(event, topic)
+	}
+}
+
+func (cx *Cronx) AddListener(listeners ...Listener) {
+	cx.listenerLock.Lock()
+	defer cx.listenerLock.Unlock()
+	cx.listeners = append(cx.listeners, listeners...)
+}
+
+func (cx *Cronx) emit(cj *cronJob, eventType EventType, start time.Time, duration time.Duration, err error) {
+	cx.listenerLock.RLock()
+	listeners := cx.listeners
+	cx.listenerLock.RUnlock()
+	if len(listeners) == 0 {
+		return
+	}
+	event := Event{
+		Type:     eventType,
+		Key:      cj.key,
+		Name:     cj.name(),
+		Metadata: userMetadata(cj.md),
+		Start:    start,
+		Duration: duration,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	for _, listener := range listeners {
+		notifyListener(listener, event)
+	}
+}
+
+func notifyListener(listener Listener, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logx.Errorf("panic found in the listener of job %s : %v", event.Key, r)
+		}
+	}()
+	listener(event)
+}
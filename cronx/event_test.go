@@ -0,0 +1,81 @@
+package cronx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fidelfly/gox/pubsubx"
+)
+
+func TestCronx_Events(t *testing.T) {
+	var events []Event
+	var lock sync.Mutex
+	cx := New(WithListener(func(event Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		events = append(events, event)
+	}))
+
+	failure := errors.New("failed")
+	md := map[string]string{"owner": "fidel"}
+	for _, want := range []error{nil, failure, ErrJobSkipped} {
+		result := want
+		if err := cx.RunJob(FuncJob(func(ctx context.Context) error { return result }), md); err != want {
+			t.Errorf("RunJob() = %v, want %v", err, want)
+		}
+	}
+
+	types := make([]EventType, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+		if owner := event.Metadata["owner"]; owner != "fidel" {
+			t.Errorf("owner of the event %d = %q, want fidel", i, owner)
+		}
+	}
+	want := []EventType{EventStarted, EventSucceeded, EventStarted, EventFailed, EventStarted, EventSkipped}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("event types = %v, want %v", types, want)
+	}
+	if events[3].Error != failure.Error() {
+		t.Errorf("error of the failed event = %q, want %q", events[3].Error, failure.Error())
+	}
+}
+
+func TestCronx_LastResult(t *testing.T) {
+	pxs := pubsubx.New(10)
+	finished := make(chan Event, 10)
+	pxs.Subscribe("cron.events", func(msg interface{}) error {
+		if event := msg.(Event); event.Type != EventStarted {
+			finished <- event
+		}
+		return nil
+	})
+
+	cx := New(WithEventTopic(pxs, "cron.events"))
+	failure := errors.New("failed")
+	id, err := cx.AddFunc("@every 1s", func(ctx context.Context) error { return failure })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cx.Entry(id).LastResult(); ok {
+		t.Error("LastResult() before running = true")
+	}
+
+	cx.Start()
+	defer cx.Stop()
+	select {
+	case event := <-finished:
+		if event.Type != EventFailed || event.Key != cx.Entry(id).Key() {
+			t.Errorf("event = %+v, want the failed event of %s", event, cx.Entry(id).Key())
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("event is not published")
+	}
+	if result, ok := cx.Entry(id).LastResult(); !ok || result.Error != failure {
+		t.Errorf("LastResult() = %+v, %v, want the failure", result, ok)
+	}
+}
@@ -0,0 +1,280 @@
+package cronx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/tidwall/buntdb"
+
+	"github.com/fidelfly/gox/logx"
+	"github.com/fidelfly/gox/pkg/randx"
+)
+
+// MetaLeaseName is the metadata key of the lease name, the registered name of the job is used
+// if it's not set, so the jobs added by AddNamedJob with the same name share one lease.
+// The replicas must use the same lease name for the same job.
+const MetaLeaseName = "job.meta.lease"
+
+var (
+	ErrLeaseHeld   = errors.New("lease is held by another owner")
+	ErrNoLeaseName = errors.New("job has neither lease name nor registered name")
+)
+
+// LeaseLocker is the backend of the job lease.
+type LeaseLocker interface {
+	// Acquire gets the lease for the owner, the lease already held by the owner is renewed.
+	Acquire(name string, owner string, ttl time.Duration) (bool, error)
+	// Release gives up the lease if it's held by the owner.
+	Release(name string, owner string) error
+}
+
+type leaseConfig struct {
+	owner string
+	renew time.Duration
+}
+
+type LeaseOption func(*leaseConfig)
+
+// LeaseOwner sets the owner identity of the replica, the default one is generated from
+// the host name and the process id.
+func LeaseOwner(owner string) LeaseOption {
+	return func(lc *leaseConfig) {
+		lc.owner = owner
+	}
+}
+
+// LeaseRenewInterval sets how often the lease is renewed while the job is running,
+// the default value is a third of the ttl.
+func LeaseRenewInterval(interval time.Duration) LeaseOption {
+	return func(lc *leaseConfig) {
+		lc.renew = interval
+	}
+}
+
+func defaultLeaseOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), randx.GenUUID(uuidSeed))
+}
+
+func leaseName(md *Metadata) string {
+	if md == nil {
+		return ""
+	}
+	if name, ok := md.Get(MetaLeaseName); ok && len(name) > 0 {
+		return name
+	}
+	return GetJobName(md)
+}
+
+//export
+// Singleton makes the job executed by only one replica, the replica runs the job only if it
+// gets the lease. The job fails with ErrNoLeaseName if it's neither added by name nor has MetaLeaseName,
+// since the generated job key differs between the replicas. The lease is renewed while the job is running and the context of the job
+// is canceled once the lease is lost, the renewal failed by the backend error is retried until the lease is expired. The lease is kept until it's expired after the job is done,
+// so that the replicas whose clocks are a little behind don't run the job again.
+func Singleton(locker LeaseLocker, ttl time.Duration, opts ...LeaseOption) JobMiddleware {
+	lc := &leaseConfig{}
+	for _, opt := range opts {
+		opt(lc)
+	}
+	if len(lc.owner) == 0 {
+		lc.owner = defaultLeaseOwner()
+	}
+	if lc.renew <= 0 || lc.renew >= ttl {
+		lc.renew = ttl / 3
+	}
+
+	return func(job Job) Job {
+		return FuncJob(func(ctx context.Context) error {
+			name := leaseName(GetMetadata(ctx))
+			if len(name) == 0 {
+				return ErrNoLeaseName
+			}
+			expires := time.Now().Add(ttl)
+			ok, err := locker.Acquire(name, lc.owner, ttl)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return ErrLeaseHeld
+			}
+
+			leaseCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			go keepLease(leaseCtx, cancel, locker, name, lc.owner, ttl, lc.renew, expires)
+			return job.Run(leaseCtx)
+		})
+	}
+}
+
+// keepLease renews the lease until ctx is done. The failed renewal is retried until the lease
+// is expired, the job is canceled only if the lease is held by another owner or expired.
+func keepLease(ctx context.Context, cancel context.CancelFunc, locker LeaseLocker, name, owner string, ttl, interval time.Duration, expires time.Time) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		renewed := time.Now().Add(ttl)
+		ok, err := locker.Acquire(name, owner, ttl)
+		if err == nil && ok {
+			expires = renewed
+			timer.Reset(interval)
+			continue
+		}
+		remains := time.Until(expires)
+		if err == nil || remains <= 0 {
+			if err == nil {
+				err = ErrLeaseHeld
+			}
+			logx.Warnf("lease %s is lost, the job is canceled : %v", name, err)
+			cancel()
+			return
+		}
+		logx.Warnf("renew lease %s failed, retry it before it's expired : %v", name, err)
+		if remains < interval {
+			timer.Reset(remains)
+		} else {
+			timer.Reset(interval)
+		}
+	}
+}
+
+type memoryLease struct {
+	owner   string
+	expires time.Time
+}
+
+// MemoryLeaseLocker keeps the leases in memory, it only coordinates the schedulers within one process.
+type MemoryLeaseLocker struct {
+	leases map[string]memoryLease
+	lock   sync.Mutex
+}
+
+//export
+func NewMemoryLeaseLocker() *MemoryLeaseLocker {
+	return &MemoryLeaseLocker{leases: make(map[string]memoryLease)}
+}
+
+func (ml *MemoryLeaseLocker) Acquire(name string, owner string, ttl time.Duration) (bool, error) {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+	now := time.Now()
+	if lease, ok := ml.leases[name]; ok && lease.owner != owner && lease.expires.After(now) {
+		return false, nil
+	}
+	ml.leases[name] = memoryLease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (ml *MemoryLeaseLocker) Release(name string, owner string) error {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+	if lease, ok := ml.leases[name]; ok && lease.owner == owner {
+		delete(ml.leases, name)
+	}
+	return nil
+}
+
+const leasePrefix = "cronx:lease:"
+
+// BuntLeaseLocker keeps the leases in buntdb with the expiration of the key.
+// buntdb is loaded into the memory of the process which opens the file, so it doesn't
+// coordinate the replicas running in separate processes, use RedisLeaseLocker for them.
+// It only coordinates the schedulers within one process, and the leases survive the restart.
+type BuntLeaseLocker struct {
+	db *buntdb.DB
+}
+
+//export
+func NewBuntLeaseLocker(filename string) (*BuntLeaseLocker, error) {
+	db, err := buntdb.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &BuntLeaseLocker{db: db}, nil
+}
+
+//export
+func NewBuntLeaseLockerWithDB(db *buntdb.DB) *BuntLeaseLocker {
+	return &BuntLeaseLocker{db: db}
+}
+
+func (bl *BuntLeaseLocker) Close() error {
+	return bl.db.Close()
+}
+
+func (bl *BuntLeaseLocker) Acquire(name string, owner string, ttl time.Duration) (acquired bool, err error) {
+	err = bl.db.Update(func(tx *buntdb.Tx) error {
+		holder, err := tx.Get(leasePrefix + name)
+		if err == nil && holder != owner {
+			return nil
+		}
+		if err != nil && err != buntdb.ErrNotFound {
+			return err
+		}
+		_, _, err = tx.Set(leasePrefix+name, owner, &buntdb.SetOptions{Expires: true, TTL: ttl})
+		acquired = err == nil
+		return err
+	})
+	return
+}
+
+func (bl *BuntLeaseLocker) Release(name string, owner string) error {
+	return bl.db.Update(func(tx *buntdb.Tx) error {
+		holder, err := tx.Get(leasePrefix + name)
+		if err == buntdb.ErrNotFound {
+			return nil
+		}
+		if err != nil || holder != owner {
+			return err
+		}
+		_, err = tx.Delete(leasePrefix + name)
+		return err
+	})
+}
+
+// KEYS: lease; ARGV: owner, ttl in milliseconds
+var acquireLeaseScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if holder and holder ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+
+// KEYS: lease; ARGV: owner
+var releaseLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisLeaseLocker keeps the leases in redis, so that the replicas sharing the redis are coordinated.
+type RedisLeaseLocker struct {
+	client redis.UniversalClient
+}
+
+//export
+func NewRedisLeaseLocker(client redis.UniversalClient) *RedisLeaseLocker {
+	return &RedisLeaseLocker{client: client}
+}
+
+func (rl *RedisLeaseLocker) Acquire(name string, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := acquireLeaseScript.Run(rl.client, []string{leasePrefix + name}, owner, int64(ttl/time.Millisecond)).Int64()
+	return acquired == 1, err
+}
+
+func (rl *RedisLeaseLocker) Release(name string, owner string) error {
+	return releaseLeaseScript.Run(rl.client, []string{leasePrefix + name}, owner).Err()
+}
@@ -0,0 +1,188 @@
+package cronx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+func TestSingleton(t *testing.T) {
+	locker := NewMemoryLeaseLocker()
+	var count int32
+	job := FuncJob(func(ctx context.Context) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	})
+	md := map[string]string{MetaLeaseName: "report"}
+
+	first := New(WithMiddleware(Singleton(locker, time.Minute, LeaseOwner("first"))))
+	second := New(WithMiddleware(Singleton(locker, time.Minute, LeaseOwner("second"))))
+	if err := first.RunJob(job, md); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.RunJob(job, md); err != ErrLeaseHeld {
+		t.Errorf("RunJob() of another replica = %v, want %v", err, ErrLeaseHeld)
+	}
+	if err := first.RunJob(job, md); err != nil {
+		t.Errorf("RunJob() of the lease holder = %v", err)
+	}
+	if n := atomic.LoadInt32(&count); n != 2 {
+		t.Errorf("job runs %d times, want 2", n)
+	}
+
+	if err := locker.Release("report", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.RunJob(job, md); err != nil {
+		t.Errorf("RunJob() after the release = %v", err)
+	}
+	if n := atomic.LoadInt32(&count); n != 3 {
+		t.Errorf("job runs %d times, want 3", n)
+	}
+}
+
+type losingLocker struct {
+	acquired int32
+}
+
+func (ll *losingLocker) Acquire(name string, owner string, ttl time.Duration) (bool, error) {
+	// only the first acquiring succeeds, the renewal fails
+	return atomic.AddInt32(&ll.acquired, 1) == 1, nil
+}
+
+func (ll *losingLocker) Release(name string, owner string) error {
+	return nil
+}
+
+func TestSingleton_LostLease(t *testing.T) {
+	cx := New(WithMiddleware(Singleton(&losingLocker{}, 30*time.Millisecond)))
+	err := cx.RunJob(FuncJob(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}), map[string]string{MetaLeaseName: "lost"})
+	if err != context.Canceled {
+		t.Errorf("RunJob() with the lost lease = %v, want %v", err, context.Canceled)
+	}
+}
+
+// flakyLeaseLocker fails the renewals with the transient error.
+type flakyLeaseLocker struct {
+	*MemoryLeaseLocker
+	acquired int32
+	failures int32
+}
+
+func (fl *flakyLeaseLocker) Acquire(name string, owner string, ttl time.Duration) (bool, error) {
+	if atomic.AddInt32(&fl.acquired, 1) > 1 && atomic.AddInt32(&fl.failures, -1) >= 0 {
+		return false, errors.New("connection refused")
+	}
+	return fl.MemoryLeaseLocker.Acquire(name, owner, ttl)
+}
+
+func TestSingleton_RenewRetry(t *testing.T) {
+	md := map[string]string{MetaLeaseName: "flaky"}
+	wait := FuncJob(func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(150 * time.Millisecond):
+			return nil
+		}
+	})
+
+	// the transient failures are retried before the lease is expired
+	locker := &flakyLeaseLocker{MemoryLeaseLocker: NewMemoryLeaseLocker(), failures: 2}
+	cx := New(WithMiddleware(Singleton(locker, 90*time.Millisecond, LeaseRenewInterval(20*time.Millisecond))))
+	if err := cx.RunJob(wait, md); err != nil {
+		t.Errorf("RunJob() with the transient renewal failures = %v", err)
+	}
+
+	// the job is canceled once the lease is expired
+	locker = &flakyLeaseLocker{MemoryLeaseLocker: NewMemoryLeaseLocker(), failures: 100}
+	cx = New(WithMiddleware(Singleton(locker, 50*time.Millisecond, LeaseRenewInterval(20*time.Millisecond))))
+	start := time.Now()
+	if err := cx.RunJob(wait, md); err != context.Canceled {
+		t.Errorf("RunJob() with the expired lease = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("job is canceled after %v, before the lease is expired", elapsed)
+	}
+}
+
+func TestSingleton_LeaseName(t *testing.T) {
+	job := FuncJob(func(ctx context.Context) error {
+		return nil
+	})
+	cx := New(WithMiddleware(Singleton(NewMemoryLeaseLocker(), time.Minute)))
+	// the generated job key differs between the replicas, so it's not used as the lease name
+	if err := cx.RunJob(job); err != ErrNoLeaseName {
+		t.Errorf("RunJob() without lease name error = %v, want %v", err, ErrNoLeaseName)
+	}
+
+	cx.Register("report", job)
+	id, err := cx.AddNamedJob("@daily", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := leaseName(cx.Entry(id).Meta()); name != "report" {
+		t.Errorf("lease name of the named job = %q, want report", name)
+	}
+	md := NewMetadata(map[string]string{MetaLeaseName: "daily-report"}, jobNameMeta("report"))
+	if name := leaseName(md); name != "daily-report" {
+		t.Errorf("lease name = %q, want daily-report", name)
+	}
+}
+
+func testLeaseLocker(t *testing.T, locker LeaseLocker, expire func()) {
+	acquire := func(owner string, ttl time.Duration, want bool) {
+		t.Helper()
+		if ok, err := locker.Acquire("job", owner, ttl); err != nil || ok != want {
+			t.Errorf("Acquire(%s) = %v, %v, want %v", owner, ok, err, want)
+		}
+	}
+	acquire("first", 50*time.Millisecond, true)
+	acquire("second", 50*time.Millisecond, false)
+	acquire("first", 50*time.Millisecond, true)
+
+	expire()
+	acquire("second", time.Minute, true)
+	if err := locker.Release("job", "first"); err != nil {
+		t.Errorf("Release() error = %v", err)
+	}
+	acquire("first", time.Minute, false)
+	if err := locker.Release("job", "second"); err != nil {
+		t.Errorf("Release() error = %v", err)
+	}
+	acquire("first", time.Minute, true)
+}
+
+func TestBuntLeaseLocker(t *testing.T) {
+	locker, err := NewBuntLeaseLocker(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer locker.Close()
+	testLeaseLocker(t, locker, func() {
+		time.Sleep(80 * time.Millisecond)
+	})
+}
+
+func TestRedisLeaseLocker(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	testLeaseLocker(t, NewRedisLeaseLocker(redis.NewClient(&redis.Options{Addr: mr.Addr()})), func() {
+		mr.FastForward(80 * time.Millisecond)
+	})
+}
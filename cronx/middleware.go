@@ -0,0 +1,144 @@
+package cronx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fidelfly/gox/logx"
+)
+
+var ErrJobSkipped = errors.New("job is skipped since the previous run is not finished")
+
+// IsSkipped reports whether the job is not executed due to the policy of the middleware.
+func IsSkipped(err error) bool {
+	return err == ErrJobSkipped || err == ErrLeaseHeld
+}
+
+//export
+// Retry runs the job again after the failure with the exponential backoff, the delay starts from
+// initial and is doubled after each retry until it reaches max. Non-positive max means no limit.
+// The skipped run and the canceled context are not retried.
+func Retry(retries int, initial time.Duration, max time.Duration) JobMiddleware {
+	return func(job Job) Job {
+		return FuncJob(func(ctx context.Context) (err error) {
+			delay := initial
+			for i := 0; ; i++ {
+				err = job.Run(ctx)
+				if err == nil || i >= retries || IsSkipped(err) || ctx.Err() != nil {
+					return
+				}
+				logx.Warnf("job %s failed, retry after %v : %v", GetJobKey(GetMetadata(ctx)), delay, err)
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				delay *= 2
+				if max > 0 && delay > max {
+					delay = max
+				}
+			}
+		})
+	}
+}
+
+//export
+// Timeout cancels the context of the job once the run lasts longer than d.
+func Timeout(d time.Duration) JobMiddleware {
+	return func(job Job) Job {
+		return FuncJob(func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return job.Run(ctx)
+		})
+	}
+}
+
+//export
+// SkipIfStillRunning skips the run if the previous run of the job is not finished,
+// ErrJobSkipped is returned for the skipped run.
+func SkipIfStillRunning() JobMiddleware {
+	return func(job Job) Job {
+		var running int32
+		return FuncJob(func(ctx context.Context) error {
+			if !atomic.CompareAndSwapInt32(&running, 0, 1) {
+				return ErrJobSkipped
+			}
+			defer atomic.StoreInt32(&running, 0)
+			return job.Run(ctx)
+		})
+	}
+}
+
+//export
+// DelayIfStillRunning makes the run wait until the previous run of the job is finished.
+func DelayIfStillRunning() JobMiddleware {
+	return func(job Job) Job {
+		var lock sync.Mutex
+		return FuncJob(func(ctx context.Context) error {
+			start := time.Now()
+			lock.Lock()
+			defer lock.Unlock()
+			if delay := time.Since(start); delay > time.Minute {
+				logx.Infof("job %s is delayed for %v", GetJobKey(GetMetadata(ctx)), delay)
+			}
+			return job.Run(ctx)
+		})
+	}
+}
+
+//export
+// Recover turns the panic of the job into an error with the stack.
+func Recover() JobMiddleware {
+	return func(job Job) Job {
+		return FuncJob(func(ctx context.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					buf := make([]byte, 64<<10)
+					buf = buf[:runtime.Stack(buf, false)]
+					err = fmt.Errorf("panic found during running job %s : %v\n%s", GetJobKey(GetMetadata(ctx)), r, buf)
+				}
+			}()
+			return job.Run(ctx)
+		})
+	}
+}
+
+//export
+// Logging logs the outcome of each run with the metadata of the job, the standard logger
+// is used if logger is nil.
+func Logging(logger *logx.Logger) JobMiddleware {
+	if logger == nil {
+		logger = logx.StandardLogger()
+	}
+	return func(job Job) Job {
+		return FuncJob(func(ctx context.Context) error {
+			start := time.Now()
+			err := job.Run(ctx)
+			fields := make(map[string]interface{})
+			if md := GetMetadata(ctx); md != nil {
+				for k, v := range md.Map() {
+					fields[k] = v
+				}
+			}
+			fields["duration"] = time.Since(start)
+			entry := logger.WithFields(fields)
+			switch {
+			case err == nil:
+				entry.Info("job succeeded")
+			case IsSkipped(err):
+				entry.WithError(err).Info("job skipped")
+			default:
+				entry.WithError(err).Error("job failed")
+			}
+			return err
+		})
+	}
+}
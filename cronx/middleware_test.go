@@ -0,0 +1,91 @@
+package cronx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/fidelfly/gox/logx"
+)
+
+func TestRetry(t *testing.T) {
+	var count int32
+	job := AttachMiddleware(FuncJob(func(ctx context.Context) error {
+		if atomic.AddInt32(&count, 1) < 3 {
+			return errors.New("failed")
+		}
+		return nil
+	}), Retry(3, time.Millisecond, 2*time.Millisecond))
+	if err := job.Run(context.Background()); err != nil {
+		t.Errorf("Run() = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("job runs %d times, want 3", count)
+	}
+
+	count = 0
+	job = AttachMiddleware(FuncJob(func(ctx context.Context) error {
+		atomic.AddInt32(&count, 1)
+		return ErrJobSkipped
+	}), Retry(3, time.Millisecond, 0))
+	if err := job.Run(context.Background()); err != ErrJobSkipped {
+		t.Errorf("Run() = %v, want %v", err, ErrJobSkipped)
+	}
+	if count != 1 {
+		t.Errorf("skipped job runs %d times, want 1", count)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	job := AttachMiddleware(FuncJob(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}), Timeout(10*time.Millisecond))
+	if err := job.Run(context.Background()); err != context.DeadlineExceeded {
+		t.Errorf("Run() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSkipIfStillRunning(t *testing.T) {
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	job := AttachMiddleware(FuncJob(func(ctx context.Context) error {
+		close(started)
+		<-finish
+		return nil
+	}), SkipIfStillRunning())
+
+	done := make(chan error)
+	go func() { done <- job.Run(context.Background()) }()
+	<-started
+	if err := job.Run(context.Background()); err != ErrJobSkipped {
+		t.Errorf("Run() of the running job = %v, want %v", err, ErrJobSkipped)
+	}
+	close(finish)
+	if err := <-done; err != nil {
+		t.Errorf("Run() = %v", err)
+	}
+}
+
+func TestRecoverAndLogging(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := &logx.Logger{Logger: logrus.New()}
+	logger.Out = buf
+
+	cx := New(WithMiddleware(Logging(logger), Recover()))
+	err := cx.RunJob(FuncJob(func(ctx context.Context) error {
+		panic("oops")
+	}), map[string]string{"owner": "fidel"})
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("RunJob() of the panic = %v", err)
+	}
+	if log := buf.String(); !strings.Contains(log, "job failed") || !strings.Contains(log, "owner=fidel") {
+		t.Errorf("log = %q", log)
+	}
+}
@@ -1,6 +1,10 @@
 package cronx
 
-import "github.com/robfig/cron/v3"
+import (
+	"github.com/robfig/cron/v3"
+
+	"github.com/fidelfly/gox/pubsubx"
+)
 
 type Option func(*Cronx)
 
@@ -10,6 +14,7 @@ func WithCronOption(opts ...cron.Option) Option {
 			panic(`"WithCronOption" can be used for one time!`)
 		}
 		cronx.inCron = cron.New(opts...)
+		cronx.cronOptions = opts
 	}
 }
 
@@ -18,3 +23,29 @@ func WithMiddleware(m ...JobMiddleware) Option {
 		cronx.middlewares = append(cronx.middlewares, m...)
 	}
 }
+
+// WithStore persists the named jobs and the run history of the scheduled jobs.
+func WithStore(store JobStore) Option {
+	return func(cronx *Cronx) {
+		cronx.store = store
+	}
+}
+
+// WithHistoryLimit sets the count of run records kept for each job, non-positive value keeps all.
+func WithHistoryLimit(limit int) Option {
+	return func(cronx *Cronx) {
+		cronx.historyLimit = limit
+	}
+}
+
+// WithListener registers the listeners of the run events.
+func WithListener(listeners ...Listener) Option {
+	return func(cronx *Cronx) {
+		cronx.listeners = append(cronx.listeners, listeners...)
+	}
+}
+
+// WithEventTopic publishes the run events to the topic of pxs.
+func WithEventTopic(pxs *pubsubx.PubXSub, topic string) Option {
+	return WithListener(PublishTo(pxs, topic))
+}
@@ -0,0 +1,170 @@
+package cronx
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// JobRecord is the persisted form of a named job.
+type JobRecord struct {
+	Key      string            `json:"key"`
+	Name     string            `json:"name"`
+	Spec     string            `json:"spec,omitempty"`
+	Timer    time.Time         `json:"timer,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Prev     time.Time         `json:"prev,omitempty"`
+	Next     time.Time         `json:"next,omitempty"`
+	Paused   bool              `json:"paused,omitempty"`
+}
+
+// IsTimer reports whether the job runs only once at Timer.
+func (jr JobRecord) IsTimer() bool {
+	return len(jr.Spec) == 0
+}
+
+// RunRecord is the result of one execution of a job.
+type RunRecord struct {
+	Key      string        `json:"key"`
+	Name     string        `json:"name,omitempty"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	Skipped  bool          `json:"skipped,omitempty"`
+}
+
+func (rr RunRecord) Succeeded() bool {
+	return len(rr.Error) == 0
+}
+
+func (rr RunRecord) Failed() bool {
+	return len(rr.Error) > 0 && !rr.Skipped
+}
+
+// RunQuery filters the run history, the zero value matches all the records.
+type RunQuery struct {
+	Key        string
+	Name       string
+	Since      time.Time
+	Until      time.Time
+	FailedOnly bool
+	Limit      int
+}
+
+func (q RunQuery) Match(run RunRecord) bool {
+	if len(q.Key) > 0 && run.Key != q.Key {
+		return false
+	}
+	if len(q.Name) > 0 && run.Name != q.Name {
+		return false
+	}
+	if !q.Since.IsZero() && run.Start.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && run.Start.After(q.Until) {
+		return false
+	}
+	if q.FailedOnly && !run.Failed() {
+		return false
+	}
+	return true
+}
+
+// filterRuns returns the matched records, the latest record comes first.
+func filterRuns(runs []RunRecord, q RunQuery) []RunRecord {
+	matched := make([]RunRecord, 0)
+	for _, run := range runs {
+		if q.Match(run) {
+			matched = append(matched, run)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Start.After(matched[j].Start)
+	})
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched
+}
+
+// JobStore persists the named jobs and the run history of all the jobs.
+type JobStore interface {
+	SaveJob(record JobRecord) error
+	DeleteJob(key string) error
+	ListJobs() ([]JobRecord, error)
+
+	AddRun(run RunRecord) error
+	ListRuns(q RunQuery) ([]RunRecord, error)
+	// Keeps the latest records of the job and removes the others.
+	PruneRuns(key string, keep int) error
+}
+
+// MemoryStore keeps the jobs in memory, it's mainly used for test.
+type MemoryStore struct {
+	jobs map[string]JobRecord
+	runs map[string][]RunRecord
+	lock sync.RWMutex
+}
+
+//export
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs: make(map[string]JobRecord),
+		runs: make(map[string][]RunRecord),
+	}
+}
+
+func (ms *MemoryStore) SaveJob(record JobRecord) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	ms.jobs[record.Key] = record
+	return nil
+}
+
+func (ms *MemoryStore) DeleteJob(key string) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	delete(ms.jobs, key)
+	return nil
+}
+
+func (ms *MemoryStore) ListJobs() ([]JobRecord, error) {
+	ms.lock.RLock()
+	defer ms.lock.RUnlock()
+	records := make([]JobRecord, 0, len(ms.jobs))
+	for _, record := range ms.jobs {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (ms *MemoryStore) AddRun(run RunRecord) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	ms.runs[run.Key] = append(ms.runs[run.Key], run)
+	return nil
+}
+
+func (ms *MemoryStore) ListRuns(q RunQuery) ([]RunRecord, error) {
+	ms.lock.RLock()
+	defer ms.lock.RUnlock()
+	var runs []RunRecord
+	if len(q.Key) > 0 {
+		runs = ms.runs[q.Key]
+	} else {
+		for _, jobRuns := range ms.runs {
+			runs = append(runs, jobRuns...)
+		}
+	}
+	return filterRuns(runs, q), nil
+}
+
+func (ms *MemoryStore) PruneRuns(key string, keep int) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	if runs := ms.runs[key]; len(runs) > keep {
+		ms.runs[key] = append([]RunRecord(nil), runs[len(runs)-keep:]...)
+	}
+	return nil
+}
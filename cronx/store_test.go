@@ -0,0 +1,186 @@
+package cronx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tidwall/buntdb"
+)
+
+func TestCronx_Restore(t *testing.T) {
+	store := NewMemoryStore()
+	done := make(chan struct{}, 10)
+	job := FuncJob(func(ctx context.Context) error {
+		done <- struct{}{}
+		return errors.New("failed")
+	})
+
+	cx := New(WithStore(store))
+	cx.Register("test", job)
+	if _, err := cx.AddNamedJob("@every 1s", "test", map[string]string{"owner": "fidel"}, JobKeyMeta("every")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cx.AddNamedJob("@every 1s", "missing"); err != ErrJobNotRegistered {
+		t.Errorf("AddNamedJob() of the unregistered job = %v, want %v", err, ErrJobNotRegistered)
+	}
+
+	cx.Start()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("job is not executed")
+	}
+	<-cx.Stop().Done()
+
+	runs, err := cx.History(RunQuery{Key: "every"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Name != "test" || runs[0].Error != "failed" {
+		t.Errorf("History() = %+v, want the failed run of test", runs)
+	}
+	records, _ := cx.JobRecords()
+	if len(records) != 1 || records[0].Prev.IsZero() || records[0].Metadata["owner"] != "fidel" {
+		t.Errorf("JobRecords() = %+v, want the run job of fidel", records)
+	}
+
+	// a missed timer job runs once after restoring
+	_ = store.SaveJob(JobRecord{Key: "timer", Name: "test", Timer: time.Now().Add(-time.Hour)})
+
+	restored := New(WithStore(store))
+	restored.Register("test", job)
+	restored.Start()
+	defer restored.Stop()
+	keys := make(map[string]bool)
+	for _, entry := range restored.Entries() {
+		keys[entry.Key()] = true
+		if entry.Key() == "every" {
+			if owner, _ := entry.Meta().Get("owner"); owner != "fidel" {
+				t.Errorf("owner of the restored job = %q, want fidel", owner)
+			}
+		}
+	}
+	if !keys["every"] || !keys["timer"] {
+		t.Errorf("restored jobs = %v, want every and timer", keys)
+	}
+
+	if !waitFor(3*time.Second, func() bool {
+		runs, _ := restored.History(RunQuery{Key: "timer"})
+		return len(runs) == 1
+	}) {
+		t.Error("missed timer job is not run after restoring")
+	}
+	if !waitFor(time.Second, func() bool {
+		records, _ := store.ListJobs()
+		return len(records) == 1
+	}) {
+		t.Error("finished timer job is not removed from the store")
+	}
+}
+
+func waitFor(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return cond()
+}
+
+func TestBuntStore_Runs(t *testing.T) {
+	store, err := NewBuntStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		run := RunRecord{Key: "job", Start: start.Add(time.Duration(i) * time.Second)}
+		if i%2 == 1 {
+			run.Error = "failed"
+		}
+		if err = store.AddRun(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = store.AddRun(RunRecord{Key: "job:other", Start: start}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = store.PruneRuns("job", 3); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := store.ListRuns(RunQuery{Key: "job"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("ListRuns() after pruning returns %d runs, want 3", len(runs))
+	}
+	if latest := start.Add(4 * time.Second); runs[0].Start.UnixNano() != latest.UnixNano() {
+		t.Errorf("first run starts at %v, want the latest %v", runs[0].Start, latest)
+	}
+	if runs, _ = store.ListRuns(RunQuery{Key: "job", FailedOnly: true}); len(runs) != 1 {
+		t.Errorf("ListRuns() of the failed runs returns %d runs, want 1", len(runs))
+	}
+	if runs, _ = store.ListRuns(RunQuery{}); len(runs) != 4 {
+		t.Errorf("ListRuns() of all the jobs returns %d runs, want 4", len(runs))
+	}
+
+	if err = store.SaveJob(JobRecord{Key: "job", Name: "test", Spec: "@daily"}); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ := store.ListJobs(); len(records) != 1 {
+		t.Errorf("ListJobs() returns %d jobs, want 1", len(records))
+	}
+	for i := 0; i < 2; i++ {
+		// deleting the missing job is not an error
+		if err = store.DeleteJob("job"); err != nil {
+			t.Errorf("DeleteJob() = %v", err)
+		}
+	}
+	if records, _ := store.ListJobs(); len(records) != 0 {
+		t.Errorf("ListJobs() after deleting returns %d jobs, want 0", len(records))
+	}
+}
+
+func TestBuntStore_Corrupt(t *testing.T) {
+	store, err := NewBuntStore(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if err = store.SaveJob(JobRecord{Key: "a", Name: "test", Spec: "@daily"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.AddRun(RunRecord{Key: "a", Start: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	err = store.GetDB().Update(func(tx *buntdb.Tx) error {
+		if _, _, err := tx.Set(buntJobPrefix+"b", "{corrupt", nil); err != nil {
+			return err
+		}
+		_, _, err := tx.Set(buntRunPrefix+"a:99999999999999999999", "{corrupt", nil)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = store.SaveJob(JobRecord{Key: "c", Name: "test", Spec: "@daily"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the corrupt record is reported instead of dropping the records after it
+	if records, err := store.ListJobs(); err == nil {
+		t.Errorf("ListJobs() = %v, nil, want the decode error", records)
+	}
+	if runs, err := store.ListRuns(RunQuery{Key: "a"}); err == nil {
+		t.Errorf("ListRuns() = %v, nil, want the decode error", runs)
+	}
+}
@@ -0,0 +1,192 @@
+package errorx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultLocale is the fallback locale of DefaultCatalog.
+const DefaultLocale = "en"
+
+// DefaultCatalog is the catalog used by the package level functions and httprxr.
+var DefaultCatalog = NewCatalog(DefaultLocale)
+
+// Catalog keeps the message templates of the error codes per locale. The template refers to
+// the parameters with {name}, the details of the error are used as the parameters, e.g.
+//  order_not_found = "Order {order} is not found"
+type Catalog struct {
+	fallback string
+	messages map[string]map[string]string
+	lock     sync.RWMutex
+}
+
+//export
+// NewCatalog returns the catalog, the templates of fallback locale are used if the requested locale has none.
+func NewCatalog(fallback string) *Catalog {
+	return &Catalog{fallback: normalizeLocale(fallback), messages: make(map[string]map[string]string)}
+}
+
+// normalizeLocale turns zh_CN and ZH-cn into zh-cn.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+}
+
+// baseLocale returns zh for zh-cn, and empty for zh.
+func baseLocale(locale string) string {
+	if i := strings.LastIndex(locale, "-"); i > 0 {
+		return locale[:i]
+	}
+	return ""
+}
+
+// Add adds the templates of the locale, the existing templates of the same codes are replaced.
+func (c *Catalog) Add(locale string, templates map[string]string) {
+	locale = normalizeLocale(locale)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	messages, ok := c.messages[locale]
+	if !ok {
+		messages = make(map[string]string, len(templates))
+		c.messages[locale] = messages
+	}
+	for code, template := range templates {
+		messages[code] = template
+	}
+}
+
+// LoadFile adds the templates in the file, the format is decided by the extension (.toml or .json).
+// The file maps the locales to the templates of the codes:
+//  [en]
+//  order_not_found = "Order {order} is not found"
+//  [zh-CN]
+//  order_not_found = "订单 {order} 不存在"
+func (c *Catalog) LoadFile(files ...string) error {
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		locales := make(map[string]map[string]string)
+		switch ext := strings.ToLower(filepath.Ext(file)); ext {
+		case ".json":
+			err = json.Unmarshal(data, &locales)
+		case ".toml":
+			_, err = toml.Decode(string(data), &locales)
+		default:
+			err = fmt.Errorf("unsupported catalog format %s", ext)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load catalog %s : %v", file, err)
+		}
+		for locale, templates := range locales {
+			c.Add(locale, templates)
+		}
+	}
+	return nil
+}
+
+// Locales returns the locales which have templates.
+func (c *Catalog) Locales() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	locales := make([]string, 0, len(c.messages))
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+	return locales
+}
+
+// Match returns the first supported locale in the preferred ones, zh-cn falls back to zh if
+// only zh is supported. The fallback locale is returned if none is supported.
+func (c *Catalog) Match(preferred ...string) string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for _, locale := range preferred {
+		for locale = normalizeLocale(locale); len(locale) > 0; locale = baseLocale(locale) {
+			if _, ok := c.messages[locale]; ok {
+				return locale
+			}
+		}
+	}
+	return c.fallback
+}
+
+// Template returns the template of the code, the lookup falls back from zh-cn to zh and then the fallback locale.
+func (c *Catalog) Template(locale string, code string) (string, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for locale = normalizeLocale(locale); len(locale) > 0; locale = baseLocale(locale) {
+		if template, ok := c.messages[locale][code]; ok {
+			return template, true
+		}
+	}
+	template, ok := c.messages[c.fallback][code]
+	return template, ok
+}
+
+// Format renders the template of the code with the parameters.
+func (c *Catalog) Format(locale string, code string, params map[string]interface{}) (string, bool) {
+	template, ok := c.Template(locale, code)
+	if !ok {
+		return "", false
+	}
+	return formatTemplate(template, params), true
+}
+
+// Message renders the message of the error in the locale with its details, the message of
+// the error is returned if there's no template of its code. Only the details of err itself are
+// rendered, the details of the errors it wraps are kept internal.
+func (c *Catalog) Message(locale string, err Error) string {
+	var details map[string]interface{}
+	if de, ok := err.(interface{ Details() map[string]interface{} }); ok {
+		details = de.Details()
+	}
+	if msg, ok := c.Format(locale, err.Code(), details); ok {
+		return msg
+	}
+	return err.Message()
+}
+
+// formatTemplate replaces {name} with the parameter, the unknown name is kept as it is.
+func formatTemplate(template string, params map[string]interface{}) string {
+	if len(params) == 0 || !strings.Contains(template, "{") {
+		return template
+	}
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		sb.WriteString(template[:start])
+		if value, ok := params[template[start+1:end]]; ok {
+			sb.WriteString(fmt.Sprint(value))
+		} else {
+			sb.WriteString(template[start : end+1])
+		}
+		template = template[end+1:]
+	}
+	sb.WriteString(template)
+	return sb.String()
+}
+
+// LoadCatalog adds the templates in the files to DefaultCatalog.
+func LoadCatalog(files ...string) error {
+	return DefaultCatalog.LoadFile(files...)
+}
+
+// LocalMessage renders the message of the error in the locale with DefaultCatalog.
+func LocalMessage(locale string, err Error) string {
+	return DefaultCatalog.Message(locale, err)
+}
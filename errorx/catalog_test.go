@@ -0,0 +1,79 @@
+package errorx
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCatalogFile(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestCatalog_LoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tomlFile := writeCatalogFile(t, dir, "errors.toml", `
+[en]
+order_not_found = "Order {order} is not found"
+out_of_stock = "{item} is out of stock"
+
+[zh_CN]
+order_not_found = "订单 {order} 不存在"
+`)
+	jsonFile := writeCatalogFile(t, dir, "errors.json", `{"de": {"order_not_found": "Bestellung {order} nicht gefunden"}}`)
+
+	c := NewCatalog("en")
+	if err := c.LoadFile(tomlFile, jsonFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.LoadFile(writeCatalogFile(t, dir, "errors.ini", "")); err == nil {
+		t.Error("LoadFile() of the unknown format returns nil error")
+	}
+
+	notFound := New("order_not_found", "order is not found", WithDetail("order", 42))
+	messages := []struct {
+		locale string
+		err    Error
+		want   string
+	}{
+		{"en-US", notFound, "Order 42 is not found"},
+		{"zh-CN", notFound, "订单 42 不存在"},
+		{"de-AT", notFound, "Bestellung 42 nicht gefunden"},
+		// unknown locale falls back to en, and the unknown code to the message of the error
+		{"fr", notFound, "Order 42 is not found"},
+		{"zh-CN", New("out_of_stock", ""), "{item} is out of stock"},
+		{"en", New("unknown", "failed"), "failed"},
+		// the details of the wrapped error are not rendered
+		{"en", Wrap(New("stock_query_failed", "", WithDetail("item", "internal")), "out_of_stock"), "{item} is out of stock"},
+		{"en", Wrap(New("stock_query_failed", "", WithDetail("item", "internal")), "out_of_stock", WithDetail("item", "pen")), "pen is out of stock"},
+	}
+	for _, tt := range messages {
+		if got := c.Message(tt.locale, tt.err); got != tt.want {
+			t.Errorf("Message(%s, %s) = %q, want %q", tt.locale, tt.err.Code(), got, tt.want)
+		}
+	}
+
+	matches := []struct {
+		preferred []string
+		want      string
+	}{
+		{[]string{"fr", "zh-CN"}, "zh-cn"},
+		{[]string{"de-CH", "en"}, "de"},
+		{[]string{"fr"}, "en"},
+	}
+	for _, tt := range matches {
+		if got := c.Match(tt.preferred...); got != tt.want {
+			t.Errorf("Match(%v) = %q, want %q", tt.preferred, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package errorx
+
+import "net/http"
+
+// Category classifies the errors, it decides the HTTP status of the response.
+type Category string
+
+// Categories of the errors
+const (
+	CategoryUnknown      Category = ""
+	CategoryInvalid      Category = "invalid"
+	CategoryUnauthorized Category = "unauthorized"
+	CategoryForbidden    Category = "forbidden"
+	CategoryNotFound     Category = "not_found"
+	CategoryConflict     Category = "conflict"
+	CategoryTooMany      Category = "too_many_requests"
+	CategoryInternal     Category = "internal"
+	CategoryUnavailable  Category = "unavailable"
+	CategoryTimeout      Category = "timeout"
+)
+
+var categoryStatus = map[Category]int{
+	CategoryInvalid:      http.StatusBadRequest,
+	CategoryUnauthorized: http.StatusUnauthorized,
+	CategoryForbidden:    http.StatusForbidden,
+	CategoryNotFound:     http.StatusNotFound,
+	CategoryConflict:     http.StatusConflict,
+	CategoryTooMany:      http.StatusTooManyRequests,
+	CategoryInternal:     http.StatusInternalServerError,
+	CategoryUnavailable:  http.StatusServiceUnavailable,
+	CategoryTimeout:      http.StatusGatewayTimeout,
+}
+
+// HTTPStatus returns the HTTP status of the category, 500 is returned for the unknown category.
+func (c Category) HTTPStatus() int {
+	if status, ok := categoryStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// CategoryOf returns the first category found in the chain of err.
+func CategoryOf(err error) Category {
+	for ; err != nil; err = Unwrap(err) {
+		if ce, ok := err.(interface{ Category() Category }); ok {
+			if category := ce.Category(); len(category) > 0 {
+				return category
+			}
+		}
+	}
+	return CategoryUnknown
+}
+
+// HTTPStatus returns the HTTP status of the error by its category.
+func HTTPStatus(err error) int {
+	return CategoryOf(err).HTTPStatus()
+}
@@ -1,6 +1,9 @@
 package errorx
 
-import "strings"
+import (
+	"reflect"
+	"strings"
+)
 
 type Error interface {
 	// Satisfy the generic error interface.
@@ -20,13 +23,16 @@ type codeError struct {
 	origError error
 	code      string
 	message   string
+	category  Category
+	details   map[string]interface{}
+	stack     Stack
 }
 
 func (ce *codeError) Error() string {
 	if ce.origError != nil {
 		return ce.origError.Error()
 	}
-	return ""
+	return ce.message
 }
 
 func (ce *codeError) Code() string {
@@ -48,23 +54,165 @@ func (ce *codeError) OrigErr() error {
 	return ce.origError
 }
 
+// Unwrap makes errors.Is and errors.As see the original error.
+func (ce *codeError) Unwrap() error {
+	return ce.origError
+}
+
+// Category returns the category set to the error, the category of the original error is used if it's not set.
+func (ce *codeError) Category() Category {
+	if len(ce.category) > 0 {
+		return ce.category
+	}
+	return CategoryOf(ce.origError)
+}
+
+// Details returns the key/value fields of the error.
+func (ce *codeError) Details() map[string]interface{} {
+	return ce.details
+}
+
+// Stack returns the call stack captured when the error is created.
+func (ce *codeError) Stack() Stack {
+	return ce.stack
+}
+
+type Option func(*codeError)
+
+// WithCategory sets the category of the error.
+func WithCategory(category Category) Option {
+	return func(ce *codeError) {
+		ce.category = category
+	}
+}
+
+// WithMessage sets the message of the error.
+func WithMessage(message string) Option {
+	return func(ce *codeError) {
+		ce.message = message
+	}
+}
+
+// WithDetail adds the key/value field to the error.
+func WithDetail(key string, value interface{}) Option {
+	return func(ce *codeError) {
+		if ce.details == nil {
+			ce.details = make(map[string]interface{})
+		}
+		ce.details[key] = value
+	}
+}
+
+// WithDetails adds the key/value fields to the error.
+func WithDetails(details map[string]interface{}) Option {
+	return func(ce *codeError) {
+		for key, value := range details {
+			WithDetail(key, value)(ce)
+		}
+	}
+}
+
+func newCodeError(err error, code string, opts []Option) *codeError {
+	ce := &codeError{origError: err, code: code, stack: callers(4)}
+	for _, opt := range opts {
+		opt(ce)
+	}
+	return ce
+}
+
 //export
 func NewError(code, message string) Error {
-	return &codeError{
-		code:    code,
-		message: message,
-	}
+	return newCodeError(nil, code, []Option{WithMessage(message)})
 }
 
 //export
 func NewCodeError(err error, code string, message ...string) Error {
-	ce := &codeError{
-		origError: err,
-		code:      code,
-	}
+	ce := newCodeError(err, code, nil)
 	if len(message) > 0 {
 		ce.message = strings.Join(message, "\n")
 	}
 
 	return ce
 }
+
+//export
+// New returns the error with the code, the call stack is captured.
+func New(code string, message string, opts ...Option) Error {
+	return newCodeError(nil, code, append([]Option{WithMessage(message)}, opts...))
+}
+
+//export
+// Wrap returns the error with the code which wraps err, the call stack is captured.
+func Wrap(err error, code string, opts ...Option) Error {
+	return newCodeError(err, code, opts)
+}
+
+// Unwrap returns the error wrapped by err, both Unwrap and OrigErr of Error are supported.
+func Unwrap(err error) error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	case Error:
+		return e.OrigErr()
+	}
+	return nil
+}
+
+// Is reports whether target is in the chain of err, the error in the chain matches the target
+// if it's equal to the target or its Is(target) method returns true.
+func Is(err, target error) bool {
+	if target == nil {
+		return err == target
+	}
+	isComparable := reflect.TypeOf(target).Comparable()
+	for ; err != nil; err = Unwrap(err) {
+		if isComparable && err == target {
+			return true
+		}
+		if ie, ok := err.(interface{ Is(error) bool }); ok && ie.Is(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// AsError returns the first Error in the chain of err.
+func AsError(err error) (Error, bool) {
+	for ; err != nil; err = Unwrap(err) {
+		if e, ok := err.(Error); ok {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// DetailsOf collects the details of the errors in the chain, the outer error overrides the inner one.
+func DetailsOf(err error) map[string]interface{} {
+	var chain []map[string]interface{}
+	for ; err != nil; err = Unwrap(err) {
+		if de, ok := err.(interface{ Details() map[string]interface{} }); ok && len(de.Details()) > 0 {
+			chain = append(chain, de.Details())
+		}
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+	details := make(map[string]interface{})
+	for i := len(chain) - 1; i >= 0; i-- {
+		for key, value := range chain[i] {
+			details[key] = value
+		}
+	}
+	return details
+}
+
+// StackOf returns the innermost stack captured in the chain of err.
+func StackOf(err error) Stack {
+	var stack Stack
+	for ; err != nil; err = Unwrap(err) {
+		if se, ok := err.(interface{ Stack() Stack }); ok && len(se.Stack()) > 0 {
+			stack = se.Stack()
+		}
+	}
+	return stack
+}
@@ -0,0 +1,102 @@
+package errorx
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var errNoRows = errors.New("no rows")
+
+func findOrder() error {
+	return Wrap(errNoRows, "order_not_found", WithCategory(CategoryNotFound), WithDetail("order", 42))
+}
+
+func TestWrap(t *testing.T) {
+	err := Wrap(findOrder(), "query_failed", WithDetail("table", "orders"))
+
+	if !Is(err, errNoRows) || !errors.Is(err, errNoRows) {
+		t.Errorf("Is(%v) = false", errNoRows)
+	}
+	var codeErr Error
+	if !errors.As(err, &codeErr) || codeErr.Code() != "query_failed" {
+		t.Errorf("errors.As() = %v", codeErr)
+	}
+	if err.Error() != "no rows" {
+		t.Errorf("Error() = %q, want no rows", err.Error())
+	}
+
+	if category := CategoryOf(err); category != CategoryNotFound {
+		t.Errorf("CategoryOf() = %v, want %v", category, CategoryNotFound)
+	}
+	if status := HTTPStatus(err); status != http.StatusNotFound {
+		t.Errorf("HTTPStatus() = %d, want %d", status, http.StatusNotFound)
+	}
+	want := map[string]interface{}{"order": 42, "table": "orders"}
+	if details := DetailsOf(err); !reflect.DeepEqual(details, want) {
+		t.Errorf("DetailsOf() = %v, want %v", details, want)
+	}
+
+	stack := StackOf(err)
+	if len(stack) == 0 {
+		t.Fatal("StackOf() is empty")
+	}
+	if fn := stack.Frames()[0].Function; !strings.HasSuffix(fn, "findOrder") {
+		t.Errorf("the first frame is %s, want findOrder", fn)
+	}
+}
+
+type timeoutError struct {
+	op string
+}
+
+func (te timeoutError) Error() string {
+	return te.op + " timeout"
+}
+
+// Is makes all the timeout errors match, whatever the operation is.
+func (te timeoutError) Is(target error) bool {
+	_, ok := target.(timeoutError)
+	return ok
+}
+
+func TestIs(t *testing.T) {
+	err := Wrap(timeoutError{"read"}, "query_failed")
+	if !Is(err, timeoutError{}) {
+		t.Errorf("Is() = false, the Is method of the wrapped error is not used")
+	}
+	if Is(err, errNoRows) {
+		t.Errorf("Is(%v) = true, want false", errNoRows)
+	}
+	if Is(err, nil) || !Is(nil, nil) {
+		t.Errorf("Is() of nil target is wrong")
+	}
+}
+
+func TestNew(t *testing.T) {
+	err := New("invalid_name", "name is required", WithCategory(CategoryInvalid))
+	if err.Error() != "name is required" {
+		t.Errorf("Error() = %q, want name is required", err.Error())
+	}
+	if status := HTTPStatus(err); status != http.StatusBadRequest {
+		t.Errorf("HTTPStatus() = %d, want %d", status, http.StatusBadRequest)
+	}
+	if inner := Unwrap(err); inner != nil {
+		t.Errorf("Unwrap() = %v, want nil", inner)
+	}
+
+	if status := HTTPStatus(NewError("unknown", "unknown error")); status != http.StatusInternalServerError {
+		t.Errorf("HTTPStatus() of the error without category = %d", status)
+	}
+	if status := HTTPStatus(errNoRows); status != http.StatusInternalServerError {
+		t.Errorf("HTTPStatus() of the plain error = %d", status)
+	}
+	if msg := NewCodeError(errNoRows, "code", "detail").Message(); msg != "detail" {
+		t.Errorf("Message() = %q, want detail", msg)
+	}
+	if _, ok := AsError(errNoRows); ok {
+		t.Errorf("AsError() of the plain error = true")
+	}
+}
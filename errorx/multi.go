@@ -0,0 +1,155 @@
+package errorx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InvalidParamsCode is the code of MultiError.
+const InvalidParamsCode = "invalid_params"
+
+// DetailFields is the detail key of the field errors of MultiError.
+const DetailFields = "fields"
+
+// FieldError is the validation failure of the field, the field is the path such as items[2].qty.
+type FieldError struct {
+	Field   string      `json:"field"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Value   interface{} `json:"rejected_value"`
+}
+
+func (fe FieldError) Error() string {
+	if len(fe.Field) == 0 {
+		return fe.Message
+	}
+	return fe.Field + ": " + fe.Message
+}
+
+// JoinPath appends the path of the field to the prefix, items + [2] is items[2] and items[2] + qty is items[2].qty.
+func JoinPath(prefix string, path string) string {
+	switch {
+	case len(prefix) == 0:
+		return path
+	case len(path) == 0:
+		return prefix
+	case strings.HasPrefix(path, "["):
+		return prefix + path
+	default:
+		return prefix + "." + path
+	}
+}
+
+// IndexPath returns the path of the element, items + 2 is items[2].
+func IndexPath(prefix string, index int) string {
+	return fmt.Sprintf("%s[%d]", prefix, index)
+}
+
+// MultiError collects the field errors, so that all the invalid fields are reported at once.
+// It's an Error with InvalidParamsCode and CategoryInvalid, the field errors are in its details.
+type MultiError struct {
+	fields []FieldError
+}
+
+//export
+func NewMultiError() *MultiError {
+	return &MultiError{}
+}
+
+// Add adds the error of the field.
+func (me *MultiError) Add(field, code, message string, value interface{}) *MultiError {
+	me.fields = append(me.fields, FieldError{Field: field, Code: code, Message: message, Value: value})
+	return me
+}
+
+// AddError adds err as the error of the field, the code and the message are taken from Error in its chain.
+// The field errors of MultiError or FieldError in the chain are merged with the field as the prefix.
+func (me *MultiError) AddError(field string, value interface{}, err error) *MultiError {
+	if err == nil {
+		return me
+	}
+	for cause := err; cause != nil; cause = Unwrap(cause) {
+		switch e := cause.(type) {
+		case *MultiError:
+			return me.Merge(field, e)
+		case FieldError:
+			e.Field = JoinPath(field, e.Field)
+			me.fields = append(me.fields, e)
+			return me
+		}
+	}
+	code, message := InvalidParamsCode, err.Error()
+	if codeError, ok := AsError(err); ok {
+		code, message = codeError.Code(), codeError.Message()
+	}
+	return me.Add(field, code, message, value)
+}
+
+// Merge adds the field errors of other with the prefix, which is used to validate the nested objects.
+func (me *MultiError) Merge(prefix string, other *MultiError) *MultiError {
+	if other == nil {
+		return me
+	}
+	for _, fe := range other.Fields() {
+		fe.Field = JoinPath(prefix, fe.Field)
+		me.fields = append(me.fields, fe)
+	}
+	return me
+}
+
+// Fields returns the field errors in the order they're added.
+func (me *MultiError) Fields() []FieldError {
+	if me == nil {
+		return nil
+	}
+	return me.fields
+}
+
+func (me *MultiError) Len() int {
+	if me == nil {
+		return 0
+	}
+	return len(me.fields)
+}
+
+// Err returns nil if there's no field error, otherwise the MultiError itself.
+func (me *MultiError) Err() error {
+	if me == nil || len(me.fields) == 0 {
+		return nil
+	}
+	return me
+}
+
+func (me *MultiError) Error() string {
+	if me.Len() == 0 {
+		return "invalid params"
+	}
+	msgs := make([]string, len(me.fields))
+	for i, fe := range me.fields {
+		msgs[i] = fe.Error()
+	}
+	return "invalid params : " + strings.Join(msgs, "; ")
+}
+
+func (me *MultiError) Code() string {
+	return InvalidParamsCode
+}
+
+func (me *MultiError) Message() string {
+	return me.Error()
+}
+
+func (me *MultiError) OrigErr() error {
+	return nil
+}
+
+func (me *MultiError) Category() Category {
+	return CategoryInvalid
+}
+
+func (me *MultiError) Details() map[string]interface{} {
+	if me.Len() == 0 {
+		return nil
+	}
+	return map[string]interface{}{DetailFields: me.fields}
+}
@@ -0,0 +1,106 @@
+package errorx
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	Name string
+	Qty  int
+}
+
+func validateItem(it item) error {
+	errs := NewMultiError()
+	if len(it.Name) == 0 {
+		errs.Add("name", "required", "name is required", it.Name)
+	}
+	if it.Qty <= 0 {
+		errs.Add("qty", "positive", "qty must be positive", it.Qty)
+	}
+	return errs.Err()
+}
+
+func TestMultiError(t *testing.T) {
+	errs := NewMultiError()
+	if err := errs.Err(); err != nil {
+		t.Errorf("Err() of empty MultiError = %v", err)
+	}
+
+	items := []item{{"pen", 1}, {"book", 3}, {"", -1}}
+	for i, it := range items {
+		errs.AddError(IndexPath("items", i), it, validateItem(it))
+	}
+	errs.AddError("customer", "bob", New("customer_not_found", "customer is not found"))
+	errs.AddError("note", nil, errors.New("note is too long"))
+
+	want := []FieldError{
+		{Field: "items[2].name", Code: "required", Message: "name is required", Value: ""},
+		{Field: "items[2].qty", Code: "positive", Message: "qty must be positive", Value: -1},
+		{Field: "customer", Code: "customer_not_found", Message: "customer is not found", Value: "bob"},
+		{Field: "note", Code: InvalidParamsCode, Message: "note is too long", Value: nil},
+	}
+	if errs.Len() != 4 || !reflect.DeepEqual(errs.Fields(), want) {
+		t.Errorf("Fields() = %v, want %v", errs.Fields(), want)
+	}
+
+	err := Wrap(errs.Err(), "order_rejected")
+	if status := HTTPStatus(err); status != http.StatusBadRequest {
+		t.Errorf("HTTPStatus() = %d, want %d", status, http.StatusBadRequest)
+	}
+	if fields := DetailsOf(err)[DetailFields]; !reflect.DeepEqual(fields, errs.Fields()) {
+		t.Errorf("DetailsOf()[%s] = %v", DetailFields, fields)
+	}
+	if !strings.Contains(err.Error(), "items[2].qty: qty must be positive") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestMultiError_Wrapped(t *testing.T) {
+	errs := NewMultiError()
+	errs.AddError("items[0]", nil, Wrap(validateItem(item{"", 1}), "item_invalid"))
+	errs.AddError("customer", nil, Wrap(FieldError{Field: "email", Code: "email", Message: "email is invalid"}, "customer_invalid"))
+
+	want := []string{"items[0].name", "customer.email"}
+	if errs.Len() != len(want) {
+		t.Fatalf("Fields() = %v, want %v", errs.Fields(), want)
+	}
+	for i, fe := range errs.Fields() {
+		if fe.Field != want[i] {
+			t.Errorf("Fields()[%d].Field = %q, want %q", i, fe.Field, want[i])
+		}
+	}
+}
+
+func TestMultiError_Nil(t *testing.T) {
+	var errs *MultiError
+	if errs.Len() != 0 || errs.Fields() != nil || errs.Details() != nil || errs.Err() != nil {
+		t.Errorf("nil MultiError is not empty")
+	}
+	if errs.Error() != "invalid params" {
+		t.Errorf("Error() = %q", errs.Error())
+	}
+	if NewMultiError().Merge("items", nil).Len() != 0 {
+		t.Errorf("Merge() of nil adds fields")
+	}
+}
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		prefix, path string
+		want         string
+	}{
+		{"", "qty", "qty"},
+		{"items", "", "items"},
+		{"items", "[2]", "items[2]"},
+		{IndexPath("items", 2), "qty", "items[2].qty"},
+	}
+	for _, tt := range tests {
+		if got := JoinPath(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("JoinPath(%q, %q) = %q, want %q", tt.prefix, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package errorx
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const maxStackDepth = 32
+
+// Stack is the program counters of the call stack.
+type Stack []uintptr
+
+func callers(skip int) Stack {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip, pcs)
+	return Stack(pcs[:n])
+}
+
+// Frames returns the frames of the stack, the caller which creates the error is the first one.
+func (s Stack) Frames() []runtime.Frame {
+	if len(s) == 0 {
+		return nil
+	}
+	frames := make([]runtime.Frame, 0, len(s))
+	iter := runtime.CallersFrames(s)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+func (s Stack) String() string {
+	var sb strings.Builder
+	for _, frame := range s.Frames() {
+		_, _ = fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return sb.String()
+}
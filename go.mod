@@ -4,7 +4,8 @@ go 1.12
 
 require (
 	github.com/BurntSushi/toml v0.3.1
-	github.com/cskr/pubsub v1.0.2
+	github.com/alicebob/miniredis/v2 v2.8.0
+	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/gorilla/mux v1.7.2
 	github.com/gorilla/websocket v1.4.0
 	github.com/natefinch/lumberjack v2.0.0+incompatible
@@ -13,6 +14,8 @@ require (
 	github.com/satori/go.uuid v1.2.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/tidwall/buntdb v1.0.0
+	golang.org/x/sync v0.0.0-20180314180146-1d60e4601c6f
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0 // indirect
 	gopkg.in/oauth2.v3 v3.10.0
+	gopkg.in/yaml.v2 v2.2.1
 )
@@ -9,12 +9,32 @@ import (
 const defConfigFile = "config.toml"
 
 //export
+// InitTomlConfig parses the toml file, the file can be replaced by the "-config" flag
+// if the application defines it and parses the command line before.
 func InitTomlConfig(filepath string, properties interface{}) (err error) {
-	var configFile = filepath
-	flag.StringVar(&configFile, "config", defConfigFile, "Set Config File")
-	flag.Parse()
-
 	// Parse Config File
-	err = confx.ParseToml(configFile, properties)
+	err = confx.ParseToml(configFile(filepath), properties)
 	return
 }
+
+//export
+// InitConfig loads the config in layers with confx, the file is loaded before the other options.
+func InitConfig(filepath string, properties interface{}, opts ...confx.Option) error {
+	opts = append([]confx.Option{confx.File(configFile(filepath))}, opts...)
+	return confx.Load(properties, opts...)
+}
+
+func configFile(filepath string) string {
+	configFile := filepath
+	if len(configFile) == 0 {
+		configFile = defConfigFile
+	}
+	if flag.Parsed() {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "config" {
+				configFile = f.Value.String()
+			}
+		})
+	}
+	return configFile
+}
@@ -0,0 +1,184 @@
+package gosrvx
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fidelfly/gox/cronx"
+	"github.com/fidelfly/gox/httprxr"
+)
+
+// Error codes of the cron admin API
+const (
+	CronEntryNotFoundCode = "cron_entry_not_found"
+	CronOperationFailCode = "cron_operation_failed"
+	CronTimerJobCode      = "cron_timer_job"
+)
+
+// CronAdmin is the RouterPlugin which mounts the REST endpoints to inspect and control the scheduler:
+//  GET    {prefix}/entries             list the entries
+//  GET    {prefix}/entries/{id}        get the entry
+//  DELETE {prefix}/entries/{id}        remove the entry
+//  POST   {prefix}/entries/{id}/{op}   trigger, pause or resume the entry
+//  GET    {prefix}/history?key=&limit= query the run history, the store of the scheduler is required
+type CronAdmin struct {
+	cx         *cronx.Cronx
+	pathPrefix string
+	restricted bool
+	audit      bool
+}
+
+//export
+// NewCronAdmin returns the admin plugin, the endpoints are restricted and audited by default.
+func NewCronAdmin(cx *cronx.Cronx, pathPrefix string) *CronAdmin {
+	return &CronAdmin{cx: cx, pathPrefix: pathPrefix, restricted: true, audit: true}
+}
+
+func (ca *CronAdmin) Restricted(restricted bool) *CronAdmin {
+	ca.restricted = restricted
+	return ca
+}
+
+func (ca *CronAdmin) Audit(audit bool) *CronAdmin {
+	ca.audit = audit
+	return ca
+}
+
+func (ca *CronAdmin) Inject(rr *RootRouter) {
+	router := rr.PathPrefix(ca.pathPrefix).Restricted(ca.restricted).Audit(ca.audit).Subrouter()
+	router.Path("/entries").Methods(http.MethodGet).HandlerFunc(ca.ListEntries)
+	router.Path("/entries/{id:[0-9]+}").Methods(http.MethodGet).HandlerFunc(ca.GetEntry)
+	router.Path("/entries/{id:[0-9]+}").Methods(http.MethodDelete).HandlerFunc(ca.RemoveEntry)
+	router.Path("/entries/{id:[0-9]+}/{op:trigger|pause|resume}").Methods(http.MethodPost).HandlerFunc(ca.ControlEntry)
+	router.Path("/history").Methods(http.MethodGet).HandlerFunc(ca.QueryHistory)
+}
+
+type CronResult struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	Skipped  bool          `json:"skipped,omitempty"`
+}
+
+type CronEntry struct {
+	ID         int               `json:"id"`
+	Key        string            `json:"key"`
+	Name       string            `json:"name,omitempty"`
+	Next       time.Time         `json:"next"`
+	Prev       time.Time         `json:"prev"`
+	Paused     bool              `json:"paused"`
+	Metadata   map[string]string `json:"metadata"`
+	LastResult *CronResult       `json:"last_result,omitempty"`
+}
+
+func newCronEntry(entry cronx.Entry) CronEntry {
+	ce := CronEntry{
+		ID:       int(entry.ID),
+		Key:      entry.Key(),
+		Name:     entry.Name(),
+		Next:     entry.Next,
+		Prev:     entry.Prev,
+		Paused:   entry.Paused(),
+		Metadata: entry.Meta().Map(),
+	}
+	if result, ok := entry.LastResult(); ok {
+		ce.LastResult = &CronResult{Start: result.Start, Duration: result.Duration, Skipped: result.Skipped()}
+		if result.Error != nil {
+			ce.LastResult.Error = result.Error.Error()
+		}
+	}
+	return ce
+}
+
+func (ca *CronAdmin) entry(w http.ResponseWriter, r *http.Request) (cronx.Entry, bool) {
+	id := httprxr.GetRequestVars(r, "id")["id"]
+	entryID, err := strconv.Atoi(id)
+	if err != nil {
+		httprxr.ResponseJSON(w, http.StatusBadRequest, httprxr.InvalidParamError("id", id))
+		return cronx.Entry{}, false
+	}
+	entry := ca.cx.Entry(entryID)
+	if !entry.Valid() {
+		httprxr.ResponseJSON(w, http.StatusNotFound, httprxr.MakeErrorMessage(CronEntryNotFoundCode, cronx.ErrEntryNotFound))
+		return entry, false
+	}
+	return entry, true
+}
+
+func (ca *CronAdmin) ListEntries(w http.ResponseWriter, r *http.Request) {
+	list := ca.cx.Entries()
+	entries := make([]CronEntry, len(list))
+	for i, entry := range list {
+		entries[i] = newCronEntry(entry)
+	}
+	httprxr.ResponseJSON(w, http.StatusOK, entries)
+}
+
+func (ca *CronAdmin) GetEntry(w http.ResponseWriter, r *http.Request) {
+	if entry, ok := ca.entry(w, r); ok {
+		httprxr.ResponseJSON(w, http.StatusOK, newCronEntry(entry))
+	}
+}
+
+func (ca *CronAdmin) RemoveEntry(w http.ResponseWriter, r *http.Request) {
+	if entry, ok := ca.entry(w, r); ok {
+		ca.cx.Remove(int(entry.ID))
+		httprxr.ResponseJSON(w, http.StatusOK, nil)
+	}
+}
+
+func (ca *CronAdmin) ControlEntry(w http.ResponseWriter, r *http.Request) {
+	entry, ok := ca.entry(w, r)
+	if !ok {
+		return
+	}
+	var err error
+	switch op := httprxr.GetRequestVars(r, "op")["op"]; op {
+	case "trigger":
+		err = ca.cx.Trigger(int(entry.ID))
+	case "pause":
+		err = ca.cx.Pause(int(entry.ID))
+	case "resume":
+		err = ca.cx.Resume(int(entry.ID))
+	default:
+		httprxr.ResponseJSON(w, http.StatusBadRequest, httprxr.InvalidParamError("op", op))
+		return
+	}
+	if err == cronx.ErrTimerJob {
+		httprxr.ResponseJSON(w, http.StatusConflict, httprxr.MakeErrorMessage(CronTimerJobCode, err))
+		return
+	}
+	if err != nil {
+		httprxr.ResponseJSON(w, http.StatusInternalServerError, httprxr.MakeErrorMessage(CronOperationFailCode, err))
+		return
+	}
+	httprxr.ResponseJSON(w, http.StatusOK, newCronEntry(ca.cx.Entry(int(entry.ID))))
+}
+
+func (ca *CronAdmin) QueryHistory(w http.ResponseWriter, r *http.Request) {
+	vars := httprxr.ParseRequestVars(r, "key", "failed", "limit")
+	q := cronx.RunQuery{Key: vars.GetString("key")}
+	if vars.Exist("failed") {
+		failed, err := vars.GetBool("failed")
+		if err != nil {
+			httprxr.ResponseJSON(w, http.StatusBadRequest, httprxr.InvalidParamError("failed", vars.GetString("failed")))
+			return
+		}
+		q.FailedOnly = failed
+	}
+	if vars.Exist("limit") {
+		limit, err := vars.GetInt("limit")
+		if err != nil {
+			httprxr.ResponseJSON(w, http.StatusBadRequest, httprxr.InvalidParamError("limit", vars.GetString("limit")))
+			return
+		}
+		q.Limit = int(limit)
+	}
+	runs, err := ca.cx.History(q)
+	if err != nil {
+		httprxr.ResponseJSON(w, http.StatusInternalServerError, httprxr.MakeErrorMessage(CronOperationFailCode, err))
+		return
+	}
+	httprxr.ResponseJSON(w, http.StatusOK, runs)
+}
@@ -0,0 +1,140 @@
+package gosrvx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fidelfly/gox/cronx"
+	"github.com/fidelfly/gox/httprxr"
+)
+
+// failingStore fails to read the run history.
+type failingStore struct {
+	*cronx.MemoryStore
+}
+
+func (fs failingStore) ListRuns(q cronx.RunQuery) ([]cronx.RunRecord, error) {
+	return nil, errors.New("store is unavailable")
+}
+
+func serveAdmin(t *testing.T, rr *RootRouter, method, path string, target interface{}) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	rr.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	if target != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), target); err != nil {
+			t.Fatalf("%s %s responds %q : %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w.Code
+}
+
+func TestCronAdmin(t *testing.T) {
+	cx := cronx.New(cronx.WithStore(cronx.NewMemoryStore()))
+	done := make(chan struct{}, 10)
+	cx.RegisterFunc("report", func(ctx context.Context) error {
+		done <- struct{}{}
+		return nil
+	})
+	id, err := cx.AddNamedJob("@daily", "report", cronx.JobKeyMeta("daily"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	timerID := cx.AddTimerFunc(time.Now().Add(time.Hour), func(ctx context.Context) error { return nil })
+	rr := NewRouter()
+	rr.AttachPlugins(NewCronAdmin(cx, "/cron").Restricted(false).Audit(false))
+	entryPath := "/cron/entries/" + strconv.Itoa(id)
+
+	var entries []CronEntry
+	if code := serveAdmin(t, rr, http.MethodGet, "/cron/entries", &entries); code != http.StatusOK || len(entries) != 2 {
+		t.Errorf("list entries = %d, %v", code, entries)
+	}
+	var entry CronEntry
+	if code := serveAdmin(t, rr, http.MethodGet, entryPath, &entry); code != http.StatusOK || entry.Key != "daily" || entry.Name != "report" {
+		t.Errorf("get entry = %d, %+v", code, entry)
+	}
+
+	if code := serveAdmin(t, rr, http.MethodPost, entryPath+"/pause", &entry); code != http.StatusOK || !entry.Paused {
+		t.Errorf("pause entry = %d, %+v", code, entry)
+	}
+	if code := serveAdmin(t, rr, http.MethodPost, entryPath+"/trigger", nil); code != http.StatusOK {
+		t.Errorf("trigger entry = %d", code)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job is not triggered")
+	}
+	if code := serveAdmin(t, rr, http.MethodPost, entryPath+"/resume", &entry); code != http.StatusOK || entry.Paused {
+		t.Errorf("resume entry = %d, %+v", code, entry)
+	}
+
+	var msg httprxr.ResponseMessage
+	if code := serveAdmin(t, rr, http.MethodPost, "/cron/entries/"+strconv.Itoa(timerID)+"/pause", &msg); code != http.StatusConflict || msg.Code != CronTimerJobCode {
+		t.Errorf("pause timer entry = %d, %+v", code, msg)
+	}
+	if code := serveAdmin(t, rr, http.MethodDelete, entryPath, nil); code != http.StatusOK {
+		t.Errorf("remove entry = %d", code)
+	}
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, entryPath},
+		{http.MethodDelete, entryPath},
+		{http.MethodPost, entryPath + "/trigger"},
+	} {
+		msg = httprxr.ResponseMessage{}
+		if code := serveAdmin(t, rr, req.method, req.path, &msg); code != http.StatusNotFound || msg.Code != CronEntryNotFoundCode {
+			t.Errorf("%s %s of the removed entry = %d, %+v", req.method, req.path, code, msg)
+		}
+	}
+}
+
+func TestCronAdmin_History(t *testing.T) {
+	store := cronx.NewMemoryStore()
+	cx := cronx.New(cronx.WithStore(store))
+	cx.RegisterFunc("report", func(ctx context.Context) error { return errors.New("failed") })
+	id, err := cx.AddNamedJob("@daily", "report", cronx.JobKeyMeta("daily"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := NewRouter()
+	rr.AttachPlugins(NewCronAdmin(cx, "/cron").Restricted(false).Audit(false))
+	if code := serveAdmin(t, rr, http.MethodPost, "/cron/entries/"+strconv.Itoa(id)+"/trigger", nil); code != http.StatusOK {
+		t.Fatalf("trigger entry = %d", code)
+	}
+
+	var runs []cronx.RunRecord
+	deadline := time.Now().Add(time.Second)
+	for len(runs) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if code := serveAdmin(t, rr, http.MethodGet, "/cron/history?key=daily&failed=true&limit=10", &runs); code != http.StatusOK {
+			t.Fatalf("query history = %d", code)
+		}
+	}
+	if len(runs) != 1 || runs[0].Error != "failed" {
+		t.Errorf("history = %+v, want the failed run", runs)
+	}
+	if code := serveAdmin(t, rr, http.MethodGet, "/cron/history?limit=many", nil); code != http.StatusBadRequest {
+		t.Errorf("query history with the invalid limit = %d, want %d", code, http.StatusBadRequest)
+	}
+
+	// the error of the store is reported
+	failing := cronx.New(cronx.WithStore(failingStore{store}))
+	rr = NewRouter()
+	rr.AttachPlugins(NewCronAdmin(failing, "/cron").Restricted(false).Audit(false))
+	var msg httprxr.ResponseMessage
+	if code := serveAdmin(t, rr, http.MethodGet, "/cron/history", &msg); code != http.StatusInternalServerError || msg.Code != CronOperationFailCode {
+		t.Errorf("query history of the failing store = %d, %+v", code, msg)
+	}
+	// the scheduler without the store can't report the history
+	rr = NewRouter()
+	rr.AttachPlugins(NewCronAdmin(cronx.New(), "/cron").Restricted(false).Audit(false))
+	if code := serveAdmin(t, rr, http.MethodGet, "/cron/history", nil); code != http.StatusInternalServerError {
+		t.Errorf("query history without store = %d, want %d", code, http.StatusInternalServerError)
+	}
+}
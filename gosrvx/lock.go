@@ -0,0 +1,79 @@
+package gosrvx
+
+import (
+	"net/http"
+
+	"github.com/fidelfly/gox/httprxr"
+	"github.com/fidelfly/gox/lockx"
+	"github.com/fidelfly/gox/logx"
+)
+
+// Error codes of the lock admin API
+const (
+	LockNotHeldCode = "lock_not_held"
+	LockNoOwnerCode = "lock_no_owner"
+)
+
+// LockAdmin is the RouterPlugin which mounts the REST endpoints to inspect the held locks of the registry:
+//  GET    {prefix}/locks        list the held locks
+//  GET    {prefix}/locks/{name} get the lock
+//  DELETE {prefix}/locks/{name} release the lock by force
+type LockAdmin struct {
+	registry   *lockx.Registry
+	pathPrefix string
+	restricted bool
+	audit      bool
+}
+
+//export
+// NewLockAdmin returns the admin plugin, the endpoints are restricted and audited by default.
+func NewLockAdmin(registry *lockx.Registry, pathPrefix string) *LockAdmin {
+	return &LockAdmin{registry: registry, pathPrefix: pathPrefix, restricted: true, audit: true}
+}
+
+func (la *LockAdmin) Restricted(restricted bool) *LockAdmin {
+	la.restricted = restricted
+	return la
+}
+
+func (la *LockAdmin) Audit(audit bool) *LockAdmin {
+	la.audit = audit
+	return la
+}
+
+func (la *LockAdmin) Inject(rr *RootRouter) {
+	router := rr.PathPrefix(la.pathPrefix).Restricted(la.restricted).Audit(la.audit).Subrouter()
+	router.Path("/locks").Methods(http.MethodGet).HandlerFunc(la.ListLocks)
+	router.Path("/locks/{name}").Methods(http.MethodGet).HandlerFunc(la.GetLock)
+	router.Path("/locks/{name}").Methods(http.MethodDelete).HandlerFunc(la.ReleaseLock)
+}
+
+func (la *LockAdmin) ListLocks(w http.ResponseWriter, r *http.Request) {
+	httprxr.ResponseJSON(w, http.StatusOK, la.registry.Held())
+}
+
+func (la *LockAdmin) GetLock(w http.ResponseWriter, r *http.Request) {
+	name := httprxr.GetRequestVars(r, "name")["name"]
+	rl, ok := la.registry.Lookup(name)
+	if !ok {
+		httprxr.ResponseJSON(w, http.StatusNotFound, httprxr.MakeErrorMessage(LockNotHeldCode, lockx.ErrNotLocked))
+		return
+	}
+	httprxr.ResponseJSON(w, http.StatusOK, rl.Info())
+}
+
+func (la *LockAdmin) ReleaseLock(w http.ResponseWriter, r *http.Request) {
+	name := httprxr.GetRequestVars(r, "name")["name"]
+	info, err := la.registry.ForceRelease(name)
+	switch err {
+	case nil:
+	case lockx.ErrNoOwner:
+		httprxr.ResponseJSON(w, http.StatusConflict, httprxr.MakeErrorMessage(LockNoOwnerCode, err))
+		return
+	default:
+		httprxr.ResponseJSON(w, http.StatusNotFound, httprxr.MakeErrorMessage(LockNotHeldCode, err))
+		return
+	}
+	logx.Warnf("lock %s held by %s (%s) is released by %s", name, info.Owner, info.Code, GetUserKey(r))
+	httprxr.ResponseJSON(w, http.StatusOK, info)
+}
@@ -0,0 +1,75 @@
+package gosrvx
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/fidelfly/gox/httprxr"
+	"github.com/fidelfly/gox/lockx"
+)
+
+type lockAction struct {
+	owner string
+	code  string
+}
+
+func (la lockAction) GetCode() string {
+	return la.code
+}
+
+func (la lockAction) GetMessage() string {
+	return la.owner + " is running " + la.code
+}
+
+func (la lockAction) GetOwnerKey() string {
+	return la.owner
+}
+
+func TestLockAdmin(t *testing.T) {
+	registry := lockx.NewRegistry()
+	report := registry.Get("report")
+	if ok, _ := report.TryLock(lockAction{"alice", "export"}); !ok {
+		t.Fatal("TryLock() of the free lock = false")
+	}
+	registry.Get("legacy").Lock()
+	registry.Get("idle")
+	rr := NewRouter()
+	rr.AttachPlugins(NewLockAdmin(registry, "/admin").Restricted(false).Audit(false))
+
+	var locks []lockx.LockInfo
+	if code := serveAdmin(t, rr, http.MethodGet, "/admin/locks", &locks); code != http.StatusOK ||
+		len(locks) != 2 || locks[0].Name != "legacy" || locks[1].Name != "report" {
+		t.Errorf("list locks = %d, %+v", code, locks)
+	}
+	var info lockx.LockInfo
+	if code := serveAdmin(t, rr, http.MethodGet, "/admin/locks/report", &info); code != http.StatusOK ||
+		!info.Locked || info.Owner != "alice" || info.Code != "export" {
+		t.Errorf("get lock = %d, %+v", code, info)
+	}
+
+	var msg httprxr.ResponseMessage
+	if code := serveAdmin(t, rr, http.MethodGet, "/admin/locks/unknown", &msg); code != http.StatusNotFound || msg.Code != LockNotHeldCode {
+		t.Errorf("get the unknown lock = %d, %+v", code, msg)
+	}
+	msg = httprxr.ResponseMessage{}
+	if code := serveAdmin(t, rr, http.MethodDelete, "/admin/locks/legacy", &msg); code != http.StatusConflict || msg.Code != LockNoOwnerCode {
+		t.Errorf("release the lock without owner = %d, %+v", code, msg)
+	}
+	if !registry.Get("legacy").IsLocked() {
+		t.Error("the lock without owner is released")
+	}
+
+	info = lockx.LockInfo{}
+	if code := serveAdmin(t, rr, http.MethodDelete, "/admin/locks/report", &info); code != http.StatusOK || info.Owner != "alice" {
+		t.Errorf("release lock = %d, %+v", code, info)
+	}
+	if report.IsLocked() {
+		t.Error("the released lock is still locked")
+	}
+	for _, name := range []string{"report", "idle", "unknown"} {
+		msg = httprxr.ResponseMessage{}
+		if code := serveAdmin(t, rr, http.MethodDelete, "/admin/locks/"+name, &msg); code != http.StatusNotFound || msg.Code != LockNotHeldCode {
+			t.Errorf("release the free lock %s = %d, %+v", name, code, msg)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package gosrvx
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"sync"
 
+	"github.com/fidelfly/gox/confx"
 	"github.com/fidelfly/gox/logx"
 )
 
@@ -29,6 +32,39 @@ func SetupLogs(config *LogConfig) {
 	configLogger(logx.StandardLogger(), config)
 }
 
+var logWriters = make(map[*logx.Logger]io.Closer)
+var logWriterLock sync.Mutex
+
+func trackLogWriter(logger *logx.Logger, writer io.Writer) {
+	logWriterLock.Lock()
+	defer logWriterLock.Unlock()
+	if old, ok := logWriters[logger]; ok {
+		logx.CaptureError(old.Close())
+		delete(logWriters, logger)
+	}
+	if closer, ok := writer.(io.Closer); ok {
+		logWriters[logger] = closer
+	}
+}
+
+//export
+// FlushLogHook closes the rotating log files so that buffered data is written to disk.
+func FlushLogHook() ShutdownHook {
+	return func(ctx context.Context) (err error) {
+		logWriterLock.Lock()
+		defer logWriterLock.Unlock()
+		for logger, closer := range logWriters {
+			if cerr := closer.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+			delete(logWriters, logger)
+		}
+		return
+	}
+}
+
+// configLogger can be called again to re-apply the config, the previous log file is closed after
+// the new output is set.
 func configLogger(logger *logx.Logger, config *LogConfig) {
 	level, err0 := logx.ParseLevel(config.LogLevel)
 	if err0 != nil {
@@ -38,6 +74,7 @@ func configLogger(logger *logx.Logger, config *LogConfig) {
 
 	if len(config.LogFile) == 0 {
 		logger.SetOutput(os.Stdout)
+		trackLogWriter(logger, nil)
 	} else {
 		logPath := config.LogPath
 		if len(logPath) == 0 {
@@ -50,6 +87,21 @@ func configLogger(logger *logx.Logger, config *LogConfig) {
 		} else {
 			logger.SetOutput(rotate)
 		}
+		trackLogWriter(logger, rotate)
+	}
+}
+
+//export
+// ReloadLogs returns the confx change handler which re-applies the log config of the standard logger
+// when it's changed, extract picks the log config from the whole config.
+func ReloadLogs(extract func(config interface{}) *LogConfig) confx.ChangeHandler {
+	return func(oldCfg, newCfg interface{}) {
+		oldConfig, newConfig := extract(oldCfg), extract(newCfg)
+		if newConfig == nil || (oldConfig != nil && *oldConfig == *newConfig) {
+			return
+		}
+		SetupLogs(newConfig)
+		logx.Infof("Log config is re-applied, level = %s", newConfig.LogLevel)
 	}
 }
 
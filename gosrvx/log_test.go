@@ -0,0 +1,65 @@
+package gosrvx
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fidelfly/gox/confx"
+	"github.com/fidelfly/gox/logx"
+)
+
+type reloadConfig struct {
+	Log LogConfig
+}
+
+func TestReloadLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gosrvx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.toml")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("[Log]\nLogLevel = \"warn\"\n")
+
+	w, err := confx.NewWatcher(&reloadConfig{}, confx.File(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	level := logx.GetLevel()
+	defer func() {
+		logx.SetLevel(level)
+		logx.SetOutput(os.Stdout)
+		logx.CaptureError(FlushLogHook()(context.Background()))
+	}()
+	SetupLogs(&w.Current().(*reloadConfig).Log)
+	w.Subscribe(ReloadLogs(func(config interface{}) *LogConfig {
+		return &config.(*reloadConfig).Log
+	}))
+
+	writeConfig("[Log]\nLogLevel = \"debug\"\nLogPath = \"" + filepath.ToSlash(dir) + "\"\nLogFile = \"app.log\"\n")
+	if changed, err := w.Check(); !changed || err != nil {
+		t.Fatalf("Check() = %v, %v", changed, err)
+	}
+	if level := logx.GetLevel(); level != logx.DebugLevel {
+		t.Errorf("level after reloading = %v, want %v", level, logx.DebugLevel)
+	}
+	logx.Debug("written to the reloaded output")
+	logx.CaptureError(FlushLogHook()(context.Background()))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "written to the reloaded output") {
+		t.Errorf("log file = %q, the output is not reloaded", data)
+	}
+}
@@ -1,6 +1,7 @@
 package gosrvx
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/fidelfly/gox/cachex/mcache"
@@ -9,6 +10,7 @@ import (
 	"github.com/fidelfly/gox/pkg/randx"
 	"github.com/fidelfly/gox/progx"
 
+	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,6 +27,19 @@ func GetProgress(key string, code string) *progx.Progress {
 	return progx.NewProgress(nil, code)
 }
 
+//export
+// CloseProgressHook closes all the opened progress websockets.
+func CloseProgressHook() ShutdownHook {
+	return func(ctx context.Context) error {
+		for _, item := range socketCache.GetStore().Items() {
+			if wsconn, ok := item.Object.(*httprxr.WsConnect); ok {
+				logx.CaptureError(wsconn.Close(websocket.CloseGoingAway, "server is shutting down"))
+			}
+		}
+		return nil
+	}
+}
+
 //export
 func SetupProgressRoute(wsPath string, restricted bool) {
 	AttchProgressRoute(Router(), wsPath, restricted)
@@ -0,0 +1,187 @@
+package gosrvx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fidelfly/gox/httprxr"
+	"github.com/fidelfly/gox/logx"
+	"github.com/fidelfly/gox/pubsubx"
+
+	"github.com/gorilla/websocket"
+)
+
+// Frame types of the pubsub websocket
+const (
+	FrameSubscribe   = "subscribe"
+	FrameUnsubscribe = "unsubscribe"
+	FrameSubscribed  = "subscribed"
+	FrameRemoved     = "unsubscribed"
+	FrameMessage     = "message"
+	FrameError       = "error"
+
+	TopicForbiddenCode = "topic_forbidden"
+	InvalidFrameCode   = "invalid_frame"
+)
+
+// TopicAuthorizer decides whether the user is allowed to subscribe the topic or pattern,
+// the user is the authenticated user id, it's empty if the route is not restricted.
+type TopicAuthorizer func(user string, topic string) bool
+
+// PubSubRequest is the frame sent by the client.
+type PubSubRequest struct {
+	Action string `json:"action"`
+	Topic  string `json:"topic"`
+}
+
+// PubSubFrame is the frame sent to the client.
+type PubSubFrame struct {
+	Type      string                   `json:"type"`
+	Topic     string                   `json:"topic"`
+	ID        string                   `json:"id,omitempty"`
+	Timestamp *time.Time               `json:"timestamp,omitempty"`
+	Headers   map[string]string        `json:"headers,omitempty"`
+	Payload   interface{}              `json:"payload,omitempty"`
+	Error     *httprxr.ResponseMessage `json:"error,omitempty"`
+}
+
+// PubSubBridge is the RouterPlugin which streams the published messages to the websocket clients.
+// The client sends {"action":"subscribe","topic":"order.*"} to subscribe the topic or pattern,
+// and {"action":"unsubscribe","topic":"order.*"} to remove it.
+type PubSubBridge struct {
+	pxs        *pubsubx.PubXSub
+	path       string
+	restricted bool
+	authorize  TopicAuthorizer
+	capacity   int
+}
+
+//export
+// NewPubSubBridge returns the bridge plugin, the route is restricted by default.
+func NewPubSubBridge(pxs *pubsubx.PubXSub, path string) *PubSubBridge {
+	return &PubSubBridge{pxs: pxs, path: path, restricted: true, capacity: 100}
+}
+
+func (psb *PubSubBridge) Restricted(restricted bool) *PubSubBridge {
+	psb.restricted = restricted
+	return psb
+}
+
+// Authorize sets the authorizer of the topics, all the topics are allowed if it's not set.
+func (psb *PubSubBridge) Authorize(authorize TopicAuthorizer) *PubSubBridge {
+	psb.authorize = authorize
+	return psb
+}
+
+// Capacity sets the count of the pending messages of each subscription,
+// the oldest message is dropped if the client is too slow.
+func (psb *PubSubBridge) Capacity(capacity int) *PubSubBridge {
+	psb.capacity = capacity
+	return psb
+}
+
+func (psb *PubSubBridge) Inject(rr *RootRouter) {
+	rr.HandleFunc(psb.path, psb.ServeHTTP).Restricted(psb.restricted)
+}
+
+func (psb *PubSubBridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wsc := &httprxr.WsConnect{Code: "pubsub"}
+	if err := httprxr.SetupWebsocket(wsc, w, r); err != nil {
+		// the upgrader has replied the error
+		logx.Warnf("setup pubsub websocket failed : %v", err)
+		return
+	}
+	defer func() {
+		logx.CaptureError(wsc.Close(websocket.CloseNormalClosure, ""))
+	}()
+
+	session := &pubsubSession{
+		bridge: psb,
+		wsc:    wsc,
+		user:   GetUserKey(r),
+		subs:   make(map[string]pubsubx.Subscriber),
+	}
+	var cancel context.CancelFunc
+	session.ctx, cancel = context.WithCancel(context.Background())
+	// all the subscriptions of the socket are removed with the context
+	defer cancel()
+
+	wsc.AddReceiver(session.receive)
+	wsc.ListenAndServe()
+	logx.Debugf("pubsub websocket of user %s is closed", session.user)
+}
+
+type pubsubSession struct {
+	bridge *PubSubBridge
+	wsc    *httprxr.WsConnect
+	user   string
+	ctx    context.Context
+	subs   map[string]pubsubx.Subscriber
+	lock   sync.Mutex
+}
+
+func (ps *pubsubSession) sendError(topic string, code string, message string) {
+	msg := httprxr.NewErrorMessage(code, message)
+	ps.wsc.SendMessage(PubSubFrame{Type: FrameError, Topic: topic, Error: &msg})
+}
+
+func (ps *pubsubSession) receive(message interface{}) {
+	data, ok := message.([]byte)
+	if !ok {
+		return
+	}
+	var req PubSubRequest
+	if err := json.Unmarshal(data, &req); err != nil || len(req.Topic) == 0 {
+		ps.sendError(req.Topic, InvalidFrameCode, "frame must be json with action and topic")
+		return
+	}
+	switch req.Action {
+	case FrameSubscribe:
+		ps.subscribe(req.Topic)
+	case FrameUnsubscribe:
+		ps.unsubscribe(req.Topic)
+	default:
+		ps.sendError(req.Topic, InvalidFrameCode, "unknown action "+req.Action)
+	}
+}
+
+func (ps *pubsubSession) subscribe(topic string) {
+	if ps.bridge.authorize != nil && !ps.bridge.authorize(ps.user, topic) {
+		ps.sendError(topic, TopicForbiddenCode, "topic is not allowed to subscribe")
+		return
+	}
+	ps.lock.Lock()
+	if _, ok := ps.subs[topic]; !ok {
+		ps.subs[topic] = ps.bridge.pxs.SubscribeContext(ps.ctx, topic, ps.forward,
+			pubsubx.Capacity(ps.bridge.capacity), pubsubx.Overflow(pubsubx.OverflowDropOldest))
+	}
+	ps.lock.Unlock()
+	ps.wsc.SendMessage(PubSubFrame{Type: FrameSubscribed, Topic: topic})
+}
+
+func (ps *pubsubSession) unsubscribe(topic string) {
+	ps.lock.Lock()
+	sub, ok := ps.subs[topic]
+	delete(ps.subs, topic)
+	ps.lock.Unlock()
+	if ok {
+		ps.bridge.pxs.UnSubscribe(sub)
+	}
+	ps.wsc.SendMessage(PubSubFrame{Type: FrameRemoved, Topic: topic})
+}
+
+func (ps *pubsubSession) forward(ctx context.Context, env *pubsubx.Envelope) error {
+	timestamp := env.Timestamp
+	ps.wsc.SendMessage(PubSubFrame{
+		Type:      FrameMessage,
+		Topic:     env.Topic,
+		ID:        env.ID,
+		Timestamp: &timestamp,
+		Headers:   env.Headers,
+		Payload:   env.Payload,
+	})
+	return nil
+}
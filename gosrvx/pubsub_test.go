@@ -0,0 +1,102 @@
+package gosrvx
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/fidelfly/gox/pubsubx"
+)
+
+func readFrame(t *testing.T, conn *websocket.Conn) PubSubFrame {
+	t.Helper()
+	var frame PubSubFrame
+	if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.ReadJSON(&frame); err != nil {
+		t.Fatalf("read frame failed : %v", err)
+	}
+	return frame
+}
+
+func sendFrame(t *testing.T, conn *websocket.Conn, action, topic string) {
+	t.Helper()
+	if err := conn.WriteJSON(PubSubRequest{Action: action, Topic: topic}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitSubscribers waits until the count of the subscribers of the topic is n.
+func waitSubscribers(t *testing.T, pxs *pubsubx.PubXSub, topic string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for pxs.Subscribers(topic) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Subscribers(%s) = %d, want %d", topic, pxs.Subscribers(topic), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPubSubBridge(t *testing.T) {
+	pxs := pubsubx.New(10)
+	rr := NewRouter()
+	rr.AttachPlugins(NewPubSubBridge(pxs, "/pubsub").Restricted(false).Authorize(func(user string, topic string) bool {
+		return !strings.HasPrefix(topic, "secret")
+	}))
+	server := httptest.NewServer(rr)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/pubsub", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sendFrame(t, conn, FrameSubscribe, "order.*")
+	if frame := readFrame(t, conn); frame.Type != FrameSubscribed || frame.Topic != "order.*" {
+		t.Errorf("frame of subscribing = %+v", frame)
+	}
+	pxs.PublishWithHeaders(map[string]string{"tenant": "eu"}, map[string]string{"no": "1"}, "order.created")
+	frame := readFrame(t, conn)
+	if frame.Type != FrameMessage || frame.Topic != "order.created" || len(frame.ID) == 0 || frame.Headers["tenant"] != "eu" {
+		t.Errorf("frame of the message = %+v", frame)
+	}
+	if payload, _ := json.Marshal(frame.Payload); string(payload) != `{"no":"1"}` {
+		t.Errorf("payload of the message = %s", payload)
+	}
+
+	sendFrame(t, conn, FrameSubscribe, "secret.order")
+	if frame := readFrame(t, conn); frame.Type != FrameError || frame.Error == nil || frame.Error.Code != TopicForbiddenCode {
+		t.Errorf("frame of the forbidden topic = %+v", frame)
+	}
+	if n := pxs.Subscribers("secret.order"); n != 0 {
+		t.Errorf("Subscribers(secret.order) = %d, want 0", n)
+	}
+	sendFrame(t, conn, "publish", "order.created")
+	if frame := readFrame(t, conn); frame.Type != FrameError || frame.Error == nil || frame.Error.Code != InvalidFrameCode {
+		t.Errorf("frame of the unknown action = %+v", frame)
+	}
+
+	sendFrame(t, conn, FrameUnsubscribe, "order.*")
+	if frame := readFrame(t, conn); frame.Type != FrameRemoved || frame.Topic != "order.*" {
+		t.Errorf("frame of unsubscribing = %+v", frame)
+	}
+	waitSubscribers(t, pxs, "order.created", 0)
+
+	// the subscriptions are removed once the connection is closed
+	sendFrame(t, conn, FrameSubscribe, "order.#")
+	if frame := readFrame(t, conn); frame.Type != FrameSubscribed {
+		t.Errorf("frame of subscribing = %+v", frame)
+	}
+	waitSubscribers(t, pxs, "order.created", 1)
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, pxs, "order.created", 0)
+}
@@ -105,11 +105,10 @@ func (t *TokenIssuer) Inject(rr *RootRouter) {
 
 func (t *TokenIssuer) AuthFilter(w http.ResponseWriter, r *http.Request, next http.Handler) {
 	if ti, err := t.ValidateToken(w, r); err != nil {
-		if codeError, ok := err.(errorx.Error); ok {
-			httprxr.ResponseJSON(w, http.StatusUnauthorized, httprxr.ErrorMessage(codeError))
-		} else {
-			httprxr.ResponseJSON(w, http.StatusUnauthorized, httprxr.MakeErrorMessage(authx.UnauthorizedErrorCode, err))
+		if errorx.CategoryOf(err) == errorx.CategoryUnknown {
+			err = errorx.Wrap(err, authx.UnauthorizedErrorCode, errorx.WithCategory(errorx.CategoryUnauthorized))
 		}
+		httprxr.ResponseLocalError(w, r, err)
 		return
 	} else if ti != nil {
 		r = httprxr.ContextSet(r, userKey{}, ti.GetUserID(), tokenKey{}, ti)
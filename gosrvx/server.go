@@ -1,27 +1,221 @@
 package gosrvx
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fidelfly/gox/cronx"
+	"github.com/fidelfly/gox/logx"
 )
 
+const defShutdownTimeout = 30 * time.Second
+
+type ShutdownHook func(ctx context.Context) error
+
+type shutdownHook struct {
+	name string
+	hook ShutdownHook
+}
+
+// Server wraps http.Server with signal handling and ordered shutdown hooks.
+type Server struct {
+	*http.Server
+	signals         []os.Signal
+	shutdownTimeout time.Duration
+	hooks           []shutdownHook
+	hookLock        sync.Mutex
+	shutdownOnce    sync.Once
+	shutdownErr     error
+	done            chan struct{}
+}
+
+type ServerOption func(*Server)
+
+//export
+func ReadTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.ReadTimeout = d
+	}
+}
+
+//export
+func ReadHeaderTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.ReadHeaderTimeout = d
+	}
+}
+
+//export
+func WriteTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.WriteTimeout = d
+	}
+}
+
+//export
+func IdleTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.IdleTimeout = d
+	}
+}
+
+//export
+// ShutdownTimeout limits the time spent on draining requests and running hooks.
+// A zero value means no deadline.
+func ShutdownTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.shutdownTimeout = d
+	}
+}
+
+//export
+// ShutdownSignals replaces the signals (SIGINT and SIGTERM by default) which trigger the shutdown.
+func ShutdownSignals(signals ...os.Signal) ServerOption {
+	return func(s *Server) {
+		s.signals = signals
+	}
+}
+
+//export
+func WithShutdownHook(name string, hook ShutdownHook) ServerOption {
+	return func(s *Server) {
+		s.AddShutdownHook(name, hook)
+	}
+}
+
 //export
-func ListenAndServe(handler http.Handler, port int64) {
-	server := &http.Server{
-		Handler: handler,
-		Addr:    fmt.Sprintf(":%d", port),
+func NewServer(handler http.Handler, port int64, opts ...ServerOption) *Server {
+	s := &Server{
+		Server: &http.Server{
+			Handler: handler,
+			Addr:    fmt.Sprintf(":%d", port),
+		},
+		signals:         []os.Signal{syscall.SIGINT, syscall.SIGTERM},
+		shutdownTimeout: defShutdownTimeout,
+		done:            make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
+
+// AddShutdownHook registers a hook, hooks are executed in the order they are added
+// after the http server stops accepting new requests.
+func (s *Server) AddShutdownHook(name string, hook ShutdownHook) {
+	s.hookLock.Lock()
+	defer s.hookLock.Unlock()
+	s.hooks = append(s.hooks, shutdownHook{name, hook})
+}
+
+func (s *Server) ListenAndServe() error {
+	return s.serve(s.Server.ListenAndServe)
+}
 
-	log.Fatal(server.ListenAndServe())
+func (s *Server) ListenAndServeTLS(certificate string, key string) error {
+	return s.serve(func() error {
+		return s.Server.ListenAndServeTLS(certificate, key)
+	})
+}
+
+func (s *Server) serve(listen func() error) error {
+	sigChan := make(chan os.Signal, 1)
+	if len(s.signals) > 0 {
+		signal.Notify(sigChan, s.signals...)
+		defer signal.Stop(sigChan)
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- listen()
+	}()
+
+	select {
+	case err := <-errChan:
+		if err != http.ErrServerClosed {
+			return err
+		}
+		// Shutdown is invoked by someone else, wait for the hooks.
+		<-s.done
+		return s.shutdownErr
+	case sig := <-sigChan:
+		logx.Infof("Signal %s received, shutting down server %s", sig, s.Addr)
+		return s.Shutdown(context.Background())
+	}
+}
+
+// Shutdown drains the in-flight requests and then runs the shutdown hooks.
+// It is safe to call Shutdown more than once, the later calls wait for the first one.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		defer close(s.done)
+		if s.shutdownTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.shutdownTimeout)
+			defer cancel()
+		}
+
+		var errs ShutdownError
+		if err := s.Server.Shutdown(ctx); err != nil {
+			logx.Errorf("Server %s is not shutdown gracefully : %v", s.Addr, err)
+			errs = append(errs, err)
+		}
+
+		s.hookLock.Lock()
+		hooks := s.hooks
+		s.hookLock.Unlock()
+		for _, h := range hooks {
+			if err := h.hook(ctx); err != nil {
+				logx.Errorf("Shutdown hook [%s] failed : %v", h.name, err)
+				errs = append(errs, err)
+			}
+		}
+
+		if len(errs) > 0 {
+			s.shutdownErr = errs
+		}
+	})
+	<-s.done
+	return s.shutdownErr
+}
+
+// ShutdownError collects all the errors found during shutdown.
+type ShutdownError []error
+
+func (se ShutdownError) Error() string {
+	msgs := make([]string, len(se))
+	for i, err := range se {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
 }
 
 //export
-func ListenAndServeTLS(certificate string, key string, handler http.Handler, port int64) {
-	server := &http.Server{
-		Handler: handler,
-		Addr:    fmt.Sprintf(":%d", port),
+// StopCronHook stops the scheduler and waits for the running jobs.
+func StopCronHook(cx *cronx.Cronx) ShutdownHook {
+	return func(ctx context.Context) error {
+		select {
+		case <-cx.Stop().Done():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
+}
 
-	log.Fatal(server.ListenAndServeTLS(certificate, key))
+//export
+func ListenAndServe(handler http.Handler, port int64, opts ...ServerOption) error {
+	return NewServer(handler, port, opts...).ListenAndServe()
+}
+
+//export
+func ListenAndServeTLS(certificate string, key string, handler http.Handler, port int64, opts ...ServerOption) error {
+	return NewServer(handler, port, opts...).ListenAndServeTLS(certificate, key)
 }
@@ -0,0 +1,180 @@
+package gosrvx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestServer_ShutdownHooks(t *testing.T) {
+	var order []string
+	errFlush := errors.New("flush failed")
+	errClose := errors.New("close failed")
+	s := NewServer(http.NotFoundHandler(), 0,
+		WithShutdownHook("first", func(ctx context.Context) error {
+			order = append(order, "first")
+			return nil
+		}),
+		WithShutdownHook("flush", func(ctx context.Context) error {
+			order = append(order, "flush")
+			return errFlush
+		}),
+	)
+	s.AddShutdownHook("close", func(ctx context.Context) error {
+		order = append(order, "close")
+		return errClose
+	})
+
+	err := s.Shutdown(context.Background())
+	if want := []string{"first", "flush", "close"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("hooks run in %v, want %v", order, want)
+	}
+	se, ok := err.(ShutdownError)
+	if !ok {
+		t.Fatalf("Shutdown() = %v, want ShutdownError", err)
+	}
+	if len(se) != 2 || se[0] != errFlush || se[1] != errClose {
+		t.Errorf("ShutdownError = %v, want [%v %v]", se, errFlush, errClose)
+	}
+	if se.Error() != "flush failed; close failed" {
+		t.Errorf("ShutdownError.Error() = %q", se.Error())
+	}
+
+	// the later calls return the result of the first one without running the hooks again
+	if again := s.Shutdown(context.Background()); !reflect.DeepEqual(again, err) {
+		t.Errorf("second Shutdown() = %v, want %v", again, err)
+	}
+	if len(order) != 3 {
+		t.Errorf("hooks run %d times, want 3", len(order))
+	}
+}
+
+func TestServer_ShutdownTimeout(t *testing.T) {
+	var lastRun bool
+	s := NewServer(http.NotFoundHandler(), 0, ShutdownTimeout(50*time.Millisecond),
+		WithShutdownHook("slow", func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		}),
+		WithShutdownHook("last", func(ctx context.Context) error {
+			lastRun = true
+			return nil
+		}),
+	)
+
+	start := time.Now()
+	err := s.Shutdown(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown() takes %v, the timeout is not applied", elapsed)
+	}
+	se, ok := err.(ShutdownError)
+	if !ok || len(se) != 1 || se[0] != context.DeadlineExceeded {
+		t.Errorf("Shutdown() = %v, want [%v]", err, context.DeadlineExceeded)
+	}
+	if !lastRun {
+		t.Error("hook after the slow one is not run")
+	}
+}
+
+func TestServer_ShutdownConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	s := NewServer(http.NotFoundHandler(), 0, WithShutdownHook("wait", func(ctx context.Context) error {
+		<-release
+		return errors.New("wait failed")
+	}))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.Shutdown(context.Background())
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	for i, err := range errs {
+		if err == nil || err.Error() != "wait failed" {
+			t.Errorf("Shutdown() #%d = %v, want wait failed", i, err)
+		}
+	}
+}
+
+func TestServer_ShutdownSignal(t *testing.T) {
+	// keep the process alive if the signal arrives before the server listens to it
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGUSR1)
+	defer signal.Stop(guard)
+
+	hooked := make(chan struct{})
+	s := NewServer(http.NotFoundHandler(), 0, ShutdownSignals(syscall.SIGUSR1),
+		WithShutdownHook("done", func(ctx context.Context) error {
+			close(hooked)
+			return nil
+		}))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- s.ListenAndServe()
+	}()
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case err := <-result:
+			if err != nil {
+				t.Errorf("ListenAndServe() = %v, want nil", err)
+			}
+			select {
+			case <-hooked:
+			default:
+				t.Error("shutdown hook is not run")
+			}
+			return
+		case <-ticker.C:
+			if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+				t.Fatal(err)
+			}
+		case <-timeout:
+			t.Fatal("server is not shutdown by the signal")
+		}
+	}
+}
+
+func TestServer_ShutdownExternal(t *testing.T) {
+	s := NewServer(http.NotFoundHandler(), 0, ShutdownSignals(),
+		WithShutdownHook("fail", func(ctx context.Context) error {
+			return errors.New("hook failed")
+		}))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- s.ListenAndServe()
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	err := s.Shutdown(context.Background())
+	select {
+	case served := <-result:
+		if served == nil || served.Error() != err.Error() {
+			t.Errorf("ListenAndServe() = %v, want %v", served, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListenAndServe() does not return after Shutdown")
+	}
+}
@@ -54,10 +54,51 @@ func MakeErrorMessage(code string, err error, data ...map[string]interface{}) Re
 }
 
 //export
+// ErrorMessage makes the message of the error, the details of the error are added to the data.
+// Only the details of err itself are exposed, the details of the errors it wraps are kept internal.
 func ErrorMessage(err errorx.Error, data ...map[string]interface{}) ResponseMessage {
+	if details := publicDetails(err); len(details) > 0 {
+		data = append([]map[string]interface{}{details}, data...)
+	}
 	return NewResponseMessage(RespMessageType.Error, err.Code(), err.Message(), data...)
 }
 
+// publicDetails returns the copy of the details of the outermost error.
+func publicDetails(err errorx.Error) map[string]interface{} {
+	de, ok := err.(interface{ Details() map[string]interface{} })
+	if !ok {
+		return nil
+	}
+	details := de.Details()
+	if len(details) == 0 {
+		return nil
+	}
+	public := make(map[string]interface{}, len(details))
+	for key, value := range details {
+		public[key] = value
+	}
+	return public
+}
+
+//export
+// ErrorStatus returns the HTTP status of the error by its category.
+func ErrorStatus(err error) int {
+	return errorx.HTTPStatus(err)
+}
+
+//export
+// ResponseError writes the message of the error with the HTTP status derived from its category,
+// the error without errorx.Error in its chain is responded as the exception.
+func ResponseError(w http.ResponseWriter, err error, data ...map[string]interface{}) {
+	if codeError, ok := errorx.AsError(err); ok {
+		ResponseJSON(w, ErrorStatus(err), ErrorMessage(codeError, data...))
+		return
+	}
+	msg := ExceptionMessage(err)
+	msg.Data = combineData(data...)
+	ResponseJSON(w, ErrorStatus(err), msg)
+}
+
 //export
 func ExceptionMessage(err error, codes ...string) ResponseMessage {
 	code := http.StatusText(http.StatusInternalServerError)
@@ -0,0 +1,41 @@
+package httprxr
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fidelfly/gox/errorx"
+)
+
+func TestErrorMessage(t *testing.T) {
+	inner := errorx.Wrap(errors.New("connection refused"), "db_failed", errorx.WithDetail("dsn", "postgres://admin@db"))
+	err := errorx.Wrap(inner, "order_not_saved", errorx.WithMessage("order is not saved"), errorx.WithDetail("order", 42))
+
+	msg := ErrorMessage(err, map[string]interface{}{"request": "r1"})
+	if msg.Code != "order_not_saved" || msg.Message != "order is not saved" {
+		t.Errorf("ErrorMessage() = %s, %s", msg.Code, msg.Message)
+	}
+	want := map[string]interface{}{"order": 42, "request": "r1"}
+	if !reflect.DeepEqual(msg.Data, want) {
+		t.Errorf("ErrorMessage().Data = %v, want %v", msg.Data, want)
+	}
+
+	// the data of the message doesn't share the details of the error
+	msg = ErrorMessage(err)
+	msg.Data["order"] = 0
+	if details := errorx.DetailsOf(err); details["order"] != 42 {
+		t.Errorf("details of the error are changed by the message: %v", details)
+	}
+}
+
+func TestInvalidParamsError(t *testing.T) {
+	msg := InvalidParamsError(nil)
+	if msg.Code != errorx.InvalidParamsCode || msg.Data != nil {
+		t.Errorf("InvalidParamsError(nil) = %+v", msg)
+	}
+	msg = InvalidParamsError(errorx.NewMultiError().Add("name", "required", "name is required", ""))
+	if fields, ok := msg.Data[errorx.DetailFields].([]errorx.FieldError); !ok || len(fields) != 1 {
+		t.Errorf("InvalidParamsError().Data = %v", msg.Data)
+	}
+}
@@ -1,6 +1,10 @@
 package httprxr
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/fidelfly/gox/errorx"
+)
 
 const InvalidParamErrorCode = "invalid_param"
 
@@ -14,3 +18,14 @@ func InvalidParamError(param string, value ...interface{}) ResponseMessage {
 	}
 	return NewErrorMessage(InvalidParamErrorCode, message)
 }
+
+//export
+// InvalidParamsError lists all the invalid fields in the data with the key "fields",
+// each field has the path, the code, the message and the rejected value. The nil error makes the message
+// without fields.
+func InvalidParamsError(err *errorx.MultiError) ResponseMessage {
+	if err == nil {
+		err = errorx.NewMultiError()
+	}
+	return ErrorMessage(err)
+}
@@ -0,0 +1,120 @@
+package httprxr
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fidelfly/gox/errorx"
+)
+
+// ParseAcceptLanguage returns the languages of the Accept-Language header ordered by the quality,
+// the wildcard and the languages with zero quality are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if len(tag) == 0 || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			languages = append(languages, language{tag, quality})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+	tags := make([]string, len(languages))
+	for i, lang := range languages {
+		tags[i] = lang.tag
+	}
+	return tags
+}
+
+// RequestLocale resolves the locale of the request from Accept-Language with errorx.DefaultCatalog,
+// the fallback locale of the catalog is returned if none of the languages is supported.
+func RequestLocale(r *http.Request) string {
+	return errorx.DefaultCatalog.Match(ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+}
+
+//export
+// LocalErrorMessage makes the message of the error in the locale of the request, the data of the message,
+// which includes the details of the error, is used as the parameters of the template.
+func LocalErrorMessage(r *http.Request, err errorx.Error, data ...map[string]interface{}) ResponseMessage {
+	msg, _ := localErrorMessage(RequestLocale(r), err, data...)
+	return msg
+}
+
+// localErrorMessage returns whether any template of the locale is used.
+func localErrorMessage(locale string, err errorx.Error, data ...map[string]interface{}) (ResponseMessage, bool) {
+	msg := ErrorMessage(err, data...)
+	text, localized := errorx.DefaultCatalog.Format(locale, err.Code(), msg.Data)
+	if localized {
+		msg.Message = text
+	}
+	if multi, ok := err.(*errorx.MultiError); ok {
+		if _, exposed := msg.Data[errorx.DetailFields]; exposed {
+			fields, ok := localFields(locale, multi.Fields())
+			msg.Data[errorx.DetailFields] = fields
+			localized = localized || ok
+		}
+	}
+	return msg, localized
+}
+
+// localFields renders the messages of the fields with the field and the value as the parameters,
+// it returns whether any of them is rendered.
+func localFields(locale string, fields []errorx.FieldError) ([]errorx.FieldError, bool) {
+	local := make([]errorx.FieldError, len(fields))
+	localized := false
+	for i, fe := range fields {
+		params := map[string]interface{}{"field": fe.Field, "value": fe.Value}
+		if text, ok := errorx.DefaultCatalog.Format(locale, fe.Code, params); ok {
+			fe.Message = text
+			localized = true
+		}
+		local[i] = fe
+	}
+	return local, localized
+}
+
+//export
+// NewLocalErrorMessage renders the template of the code in the locale of the request with the data
+// as the parameters, the message is used if there's no template of the code.
+func NewLocalErrorMessage(r *http.Request, code, message string, data ...map[string]interface{}) ResponseMessage {
+	msg := NewErrorMessage(code, message, data...)
+	if text, ok := errorx.DefaultCatalog.Format(RequestLocale(r), code, msg.Data); ok {
+		msg.Message = text
+	}
+	return msg
+}
+
+//export
+// ResponseLocalError is ResponseError with the message in the locale of the request.
+func ResponseLocalError(w http.ResponseWriter, r *http.Request, err error, data ...map[string]interface{}) {
+	if codeError, ok := errorx.AsError(err); ok {
+		locale := RequestLocale(r)
+		msg, localized := localErrorMessage(locale, codeError, data...)
+		if localized {
+			w.Header().Set("Content-Language", locale)
+		}
+		ResponseJSON(w, ErrorStatus(err), msg)
+		return
+	}
+	ResponseError(w, err, data...)
+}
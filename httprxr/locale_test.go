@@ -0,0 +1,112 @@
+package httprxr
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/fidelfly/gox/errorx"
+)
+
+func init() {
+	errorx.DefaultCatalog.Add("en", map[string]string{
+		"order_locked": "Order {order} is locked by {user}",
+		"required":     "{field} is required",
+	})
+	errorx.DefaultCatalog.Add("zh", map[string]string{
+		"order_locked": "订单 {order} 被 {user} 锁定",
+		"required":     "{field} 必填",
+	})
+}
+
+func localeRequest(acceptLanguage string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	if len(acceptLanguage) > 0 {
+		r.Header.Set("Accept-Language", acceptLanguage)
+	}
+	return r
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"zh-CN", []string{"zh-CN"}},
+		{"en;q=0.5, zh-CN, de;q=0.8", []string{"zh-CN", "de", "en"}},
+		{"fr;q=0, *, en;q=0.3", []string{"en"}},
+		{"de;q=bad, en;q=0.9", []string{"de", "en"}},
+	}
+	for _, tt := range tests {
+		if got := ParseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRequestLocale(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"zh-CN,en;q=0.8", "zh"},
+		{"fr, en;q=0.5", "en"},
+		{"fr", errorx.DefaultLocale},
+		{"", errorx.DefaultLocale},
+	}
+	for _, tt := range tests {
+		if got := RequestLocale(localeRequest(tt.header)); got != tt.want {
+			t.Errorf("RequestLocale(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLocalErrorMessage(t *testing.T) {
+	err := errorx.New("order_locked", "order is locked", errorx.WithDetail("order", 42))
+	// the data of the caller is a parameter of the template as well as the details
+	msg := LocalErrorMessage(localeRequest("zh-CN"), err, map[string]interface{}{"user": "alice"})
+	if msg.Message != "订单 42 被 alice 锁定" {
+		t.Errorf("LocalErrorMessage() = %q", msg.Message)
+	}
+
+	fields := errorx.NewMultiError().Add("name", "required", "name is required", "")
+	msg = LocalErrorMessage(localeRequest("zh"), fields)
+	local, ok := msg.Data[errorx.DetailFields].([]errorx.FieldError)
+	if !ok || len(local) != 1 || local[0].Message != "name 必填" {
+		t.Errorf("fields of LocalErrorMessage() = %v", msg.Data[errorx.DetailFields])
+	}
+	if fields.Fields()[0].Message != "name is required" {
+		t.Errorf("fields of the error are changed: %v", fields.Fields())
+	}
+}
+
+func TestResponseLocalError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		language string
+		message  string
+	}{
+		{"template", errorx.New("order_locked", "order is locked", errorx.WithDetail("user", "bob")), "zh", "订单 {order} 被 bob 锁定"},
+		{"no template", errorx.New("order_missing", "order is missing"), "", "order is missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ResponseLocalError(w, localeRequest("zh-CN"), tt.err)
+			if got := w.Header().Get("Content-Language"); got != tt.language {
+				t.Errorf("Content-Language = %q, want %q", got, tt.language)
+			}
+			var msg ResponseMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg.Message != tt.message {
+				t.Errorf("message = %q, want %q", msg.Message, tt.message)
+			}
+		})
+	}
+}
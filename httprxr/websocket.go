@@ -20,14 +20,18 @@ type WsConnect struct {
 	Code             string
 	Decoder          WsDecoder
 	Encoder          WsEncoder
-	Status           uint
+	Status           uint // use GetStatus to read it, it's changed by the connection goroutines
 	Conn             *gws.Conn
 	Duration         time.Duration
 	receivers        []WsReceiver
 	closeHandlers    []WsCloseHandler
 	receiveLock      *sync.Mutex
 	closeHandlerLock *sync.Mutex
+	statusLock       sync.RWMutex
 	writerChan       chan interface{}
+	closeChan        chan struct{}
+	closeOnce        sync.Once
+	stopOnce         sync.Once
 }
 
 type WsDecoder func([]byte) (interface{}, error)
@@ -43,40 +47,74 @@ type WsReceiver func(interface{})
 func (wsc *WsConnect) AddReceiver(receiver WsReceiver) {
 	wsc.receiveLock.Lock()
 	defer wsc.receiveLock.Unlock()
-	if wsc.receivers == nil {
-		wsc.receivers = make([]WsReceiver, 1)
-	}
 	wsc.receivers = append(wsc.receivers, receiver)
 }
 
 func (wsc *WsConnect) AddCloseHandler(handler WsCloseHandler) {
 	wsc.closeHandlerLock.Lock()
 	defer wsc.closeHandlerLock.Unlock()
-	if wsc.closeHandlers == nil {
-		wsc.closeHandlers = make([]WsCloseHandler, 1)
-	}
 	wsc.closeHandlers = append(wsc.closeHandlers, handler)
 }
 
 func (wsc *WsConnect) SendMessage(message interface{}) {
-	if wsc.Status == OPENED {
-		wsc.writerChan <- message
+	if wsc.IsOpen() {
+		select {
+		case wsc.writerChan <- message:
+		case <-wsc.closeChan:
+		}
 	}
 }
 
 func (wsc *WsConnect) SetupConnection(ws *gws.Conn) {
 	wsc.Conn = ws
-	wsc.Status = OPENED
+	wsc.setStatus(OPENED)
 	wsc.writerChan = make(chan interface{}, 100)
+	wsc.closeChan = make(chan struct{})
+	if wsc.receiveLock == nil {
+		wsc.receiveLock = &sync.Mutex{}
+	}
+	if wsc.closeHandlerLock == nil {
+		wsc.closeHandlerLock = &sync.Mutex{}
+	}
 	wsc.Conn.SetCloseHandler(wsc.onClose)
 }
 
+// stop marks the connection closed and makes the writer return.
+func (wsc *WsConnect) stop() {
+	wsc.stopOnce.Do(func() {
+		wsc.setStatus(CLOSED)
+		close(wsc.closeChan)
+	})
+}
+
+// Close sends a close frame to the peer and releases the underlying connection,
+// so that ListenAndServe returns without waiting for the client.
+func (wsc *WsConnect) Close(code int, text string) (err error) {
+	if wsc.Conn == nil {
+		return nil
+	}
+	wsc.closeOnce.Do(func() {
+		wsc.stop()
+		err = wsc.Conn.WriteControl(gws.CloseMessage, gws.FormatCloseMessage(code, text), time.Now().Add(time.Second))
+		logx.CaptureError(wsc.Conn.Close())
+	})
+	return
+}
+
+func (wsc *WsConnect) setStatus(status uint) {
+	wsc.statusLock.Lock()
+	defer wsc.statusLock.Unlock()
+	wsc.Status = status
+}
+
 func (wsc *WsConnect) GetStatus() uint {
+	wsc.statusLock.RLock()
+	defer wsc.statusLock.RUnlock()
 	return wsc.Status
 }
 
 func (wsc *WsConnect) IsOpen() bool {
-	return wsc.Status == OPENED
+	return wsc.GetStatus() == OPENED
 }
 
 func (wsc *WsConnect) ListenAndServe() {
@@ -96,9 +134,12 @@ func (wsc *WsConnect) ListenAndServe() {
 }
 
 func (wsc *WsConnect) onClose(code int, text string) error {
-	wsc.Status = CLOSED
-	if len(wsc.closeHandlers) > 0 {
-		for _, handler := range wsc.closeHandlers {
+	wsc.setStatus(CLOSED)
+	wsc.closeHandlerLock.Lock()
+	handlers := wsc.closeHandlers
+	wsc.closeHandlerLock.Unlock()
+	if len(handlers) > 0 {
+		for _, handler := range handlers {
 			err := handler(code, text)
 			if err != nil {
 				return err
@@ -109,17 +150,22 @@ func (wsc *WsConnect) onClose(code int, text string) error {
 }
 
 func (wsc *WsConnect) notifyReceiver(message interface{}) {
-	if len(wsc.receivers) > 0 {
-		for _, receiver := range wsc.receivers {
+	wsc.receiveLock.Lock()
+	receivers := wsc.receivers
+	wsc.receiveLock.Unlock()
+	if len(receivers) > 0 {
+		for _, receiver := range receivers {
 			receiver(message)
 		}
 	}
 }
 
 func (wsc *WsConnect) startReader() {
-	if wsc.Status == OPENED {
+	// the writer is useless once the peer is gone
+	defer wsc.stop()
+	if wsc.IsOpen() {
 		for {
-			if wsc.Status == OPENED {
+			if wsc.IsOpen() {
 				_, p, err := wsc.Conn.ReadMessage()
 				if err != nil {
 					if gws.IsUnexpectedCloseError(err, gws.CloseGoingAway, gws.CloseAbnormalClosure) {
@@ -180,9 +226,11 @@ func (wsc *WsConnect) startWriter() {
 				wsc.sendToReceiver(message)
 				break
 			case <-wsTicker.C: //check ws connection status for every 30 seconds
-				if wsc.Status != OPENED {
+				if !wsc.IsOpen() {
 					return
 				}
+			case <-wsc.closeChan:
+				return
 			}
 		}
 	} else {
@@ -193,7 +241,7 @@ func (wsc *WsConnect) startWriter() {
 						case message = <-wsc.writerChan:
 							break
 						default:
-							if wsc.Status != OPENED {
+							if !wsc.IsOpen() {
 								return
 							}
 						}
@@ -207,9 +255,11 @@ func (wsc *WsConnect) startWriter() {
 					message = nil
 				}
 			case <-wsTicker.C: //check ws connection status for every 30 seconds
-				if wsc.Status != OPENED {
+				if !wsc.IsOpen() {
 					return
 				}
+			case <-wsc.closeChan:
+				return
 			}
 		}
 	}
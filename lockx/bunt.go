@@ -0,0 +1,156 @@
+package lockx
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/tidwall/buntdb"
+)
+
+const (
+	buntLockPrefix  = "lockx:lock:"
+	buntTokenPrefix = "lockx:token:"
+)
+
+type buntLock struct {
+	Owner   string `json:"owner"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Token   uint64 `json:"token"`
+}
+
+func (bl buntLock) GetCode() string {
+	return bl.Code
+}
+
+func (bl buntLock) GetMessage() string {
+	return bl.Message
+}
+
+func (bl buntLock) GetOwnerKey() string {
+	return bl.Owner
+}
+
+// BuntLocker keeps the locks in buntdb with the expiration of the key. buntdb is loaded into the memory
+// of the process which opens the file, so it doesn't coordinate the replicas running in separate processes
+// even if they open the same file, use RedisLocker for them. It's only for the locks within one process
+// which need the locks and the fencing tokens to survive the restart.
+type BuntLocker struct {
+	db *buntdb.DB
+}
+
+//export
+// NewBuntLocker opens the file of the process-local locker, the file must not be opened by another process.
+func NewBuntLocker(filename string) (*BuntLocker, error) {
+	db, err := buntdb.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &BuntLocker{db: db}, nil
+}
+
+//export
+func NewBuntLockerWithDB(db *buntdb.DB) *BuntLocker {
+	return &BuntLocker{db: db}
+}
+
+func (bl *BuntLocker) Close() error {
+	return bl.db.Close()
+}
+
+func getBuntLock(tx *buntdb.Tx, name string) (*buntLock, error) {
+	val, err := tx.Get(buntLockPrefix + name)
+	if err != nil {
+		return nil, err
+	}
+	held := &buntLock{}
+	if err := json.Unmarshal([]byte(val), held); err != nil {
+		return nil, err
+	}
+	return held, nil
+}
+
+func setBuntLock(tx *buntdb.Tx, name string, held *buntLock, ttl time.Duration) (time.Time, error) {
+	data, err := json.Marshal(held)
+	if err != nil {
+		return time.Time{}, err
+	}
+	_, _, err = tx.Set(buntLockPrefix+name, string(data), &buntdb.SetOptions{Expires: true, TTL: ttl})
+	return time.Now().Add(ttl), err
+}
+
+func nextBuntToken(tx *buntdb.Tx, name string) (uint64, error) {
+	var token uint64
+	val, err := tx.Get(buntTokenPrefix + name)
+	if err == nil {
+		if token, err = strconv.ParseUint(val, 10, 64); err != nil {
+			return 0, err
+		}
+	} else if err != buntdb.ErrNotFound {
+		return 0, err
+	}
+	token++
+	_, _, err = tx.Set(buntTokenPrefix+name, strconv.FormatUint(token, 10), nil)
+	return token, err
+}
+
+func (bl *BuntLocker) Acquire(name string, action Action, ttl time.Duration) (lease *Lease, acquired bool, err error) {
+	err = bl.db.Update(func(tx *buntdb.Tx) error {
+		held, err := getBuntLock(tx, name)
+		if err != nil && err != buntdb.ErrNotFound {
+			return err
+		}
+		if held != nil && held.Owner != action.GetOwnerKey() {
+			remains, _ := tx.TTL(buntLockPrefix + name)
+			lease = NewLease(name, held, held.Token, time.Now().Add(remains))
+			return nil
+		}
+		if held == nil {
+			held = &buntLock{}
+			if held.Token, err = nextBuntToken(tx, name); err != nil {
+				return err
+			}
+		}
+		held.Owner, held.Code, held.Message = action.GetOwnerKey(), action.GetCode(), action.GetMessage()
+		expires, err := setBuntLock(tx, name, held, ttl)
+		if err != nil {
+			return err
+		}
+		lease, acquired = NewLease(name, held, held.Token, expires), true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return
+}
+
+func (bl *BuntLocker) Renew(lease *Lease, ttl time.Duration) (expires time.Time, err error) {
+	err = bl.db.Update(func(tx *buntdb.Tx) error {
+		held, err := getBuntLock(tx, lease.Name)
+		if err == buntdb.ErrNotFound || (err == nil && (held.Owner != lease.Owner || held.Token != lease.Token)) {
+			return ErrLockLost
+		}
+		if err != nil {
+			return err
+		}
+		expires, err = setBuntLock(tx, lease.Name, held, ttl)
+		return err
+	})
+	return
+}
+
+func (bl *BuntLocker) Release(lease *Lease) error {
+	return bl.db.Update(func(tx *buntdb.Tx) error {
+		held, err := getBuntLock(tx, lease.Name)
+		if err == buntdb.ErrNotFound {
+			return nil
+		}
+		if err != nil || held.Owner != lease.Owner || held.Token != lease.Token {
+			return err
+		}
+		_, err = tx.Delete(buntLockPrefix + lease.Name)
+		return err
+	})
+}
@@ -0,0 +1,273 @@
+package lockx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fidelfly/gox/logx"
+)
+
+var (
+	ErrNoOwner  = errors.New("action of the lock has no owner key")
+	ErrLockLost = errors.New("lock is expired or taken by another owner")
+)
+
+// Locker is the backend of the shared lock, the lock is identified by the name and held by
+// the owner key of the action until it's released or the ttl is expired.
+type Locker interface {
+	// Acquire gets the lock for the owner of the action, the lock already held by the owner is renewed
+	// with the same token. The lease of the holder is returned if the lock is held by another owner.
+	Acquire(name string, action Action, ttl time.Duration) (lease *Lease, acquired bool, err error)
+	// Renew extends the lease, ErrLockLost is returned if the lease is no longer held.
+	Renew(lease *Lease, ttl time.Duration) (expires time.Time, err error)
+	// Release gives up the lease if it's still held.
+	Release(lease *Lease) error
+}
+
+// Lease is the lock held by the owner, it describes the holder as an Action.
+// Token is the fencing token which increases each time the lock is acquired by a new holder,
+// the storage guarded by the lock should reject the writes with a token lower than the latest one.
+type Lease struct {
+	Name    string `json:"name"`
+	Owner   string `json:"owner"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Token   uint64 `json:"token"`
+
+	expires time.Time
+	locker  Locker
+	shared  *SharedLock
+	stop    chan struct{}
+	lost    chan struct{}
+	once    sync.Once
+	lock    sync.RWMutex
+}
+
+//export
+// NewLease is used by the Locker implementations.
+func NewLease(name string, action Action, token uint64, expires time.Time) *Lease {
+	return &Lease{
+		Name:    name,
+		Owner:   action.GetOwnerKey(),
+		Code:    action.GetCode(),
+		Message: action.GetMessage(),
+		Token:   token,
+		expires: expires,
+		stop:    make(chan struct{}),
+		lost:    make(chan struct{}),
+	}
+}
+
+func (l *Lease) GetCode() string {
+	return l.Code
+}
+
+func (l *Lease) GetMessage() string {
+	return l.Message
+}
+
+func (l *Lease) GetOwnerKey() string {
+	return l.Owner
+}
+
+// Expires returns the time when the lease is expired unless it's renewed.
+func (l *Lease) Expires() time.Time {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.expires
+}
+
+func (l *Lease) setExpires(expires time.Time) {
+	l.lock.Lock()
+	l.expires = expires
+	l.lock.Unlock()
+}
+
+// Lost is closed once the renewal of the lease fails, the holder should stop the work on the resource.
+func (l *Lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Unlock stops the renewal and releases the lease.
+func (l *Lease) Unlock() error {
+	l.once.Do(func() {
+		close(l.stop)
+	})
+	if l.shared != nil {
+		l.shared.forget(l)
+	}
+	if l.locker == nil {
+		return nil
+	}
+	return l.locker.Release(l)
+}
+
+// active reports whether the lease is neither unlocked nor lost.
+func (l *Lease) active() bool {
+	select {
+	case <-l.stop:
+		return false
+	case <-l.lost:
+		return false
+	default:
+		return true
+	}
+}
+
+// keep renews the lease until it's unlocked. The failed renewal is retried until the lease is expired,
+// the lease is lost at once if it's taken by another owner.
+func (l *Lease) keep(ttl time.Duration, interval time.Duration) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-timer.C:
+		}
+		expires, err := l.locker.Renew(l, ttl)
+		if err == nil {
+			l.setExpires(expires)
+			timer.Reset(interval)
+			continue
+		}
+		remains := time.Until(l.Expires())
+		if err == ErrLockLost || remains <= 0 {
+			logx.Warnf("lock %s of %s is lost : %v", l.Name, l.Owner, err)
+			close(l.lost)
+			return
+		}
+		logx.Warnf("renew lock %s of %s failed, retry it before it's expired : %v", l.Name, l.Owner, err)
+		if remains < interval {
+			timer.Reset(remains)
+		} else {
+			timer.Reset(interval)
+		}
+	}
+}
+
+type lockConfig struct {
+	ttl   time.Duration
+	retry time.Duration
+	renew time.Duration
+}
+
+type LockOption func(*lockConfig)
+
+// LockTTL sets how long the lease lasts without the renewal, the default value is 30 seconds.
+func LockTTL(ttl time.Duration) LockOption {
+	return func(lc *lockConfig) {
+		lc.ttl = ttl
+	}
+}
+
+// RetryInterval sets how often the waiting variants try to get the lock, the default value is 100ms.
+func RetryInterval(interval time.Duration) LockOption {
+	return func(lc *lockConfig) {
+		lc.retry = interval
+	}
+}
+
+// RenewInterval sets how often the lease is renewed until it's unlocked, the default value is
+// a third of the ttl. Negative interval disables the renewal.
+func RenewInterval(interval time.Duration) LockOption {
+	return func(lc *lockConfig) {
+		lc.renew = interval
+	}
+}
+
+// SharedLock is the lock of the resource shared by the replicas through the Locker.
+type SharedLock struct {
+	locker Locker
+	name   string
+	config lockConfig
+	leases map[string]*Lease // the renewed leases by the owner key
+	lock   sync.Mutex
+}
+
+//export
+func NewSharedLock(locker Locker, name string, opts ...LockOption) *SharedLock {
+	sl := &SharedLock{
+		locker: locker,
+		name:   name,
+		config: lockConfig{ttl: 30 * time.Second, retry: 100 * time.Millisecond},
+		leases: make(map[string]*Lease),
+	}
+	for _, opt := range opts {
+		opt(&sl.config)
+	}
+	if sl.config.renew == 0 || sl.config.renew >= sl.config.ttl {
+		sl.config.renew = sl.config.ttl / 3
+	}
+	return sl
+}
+
+func (sl *SharedLock) Name() string {
+	return sl.name
+}
+
+// TryLock gets the lock without waiting, the holder is returned if the lock is held by another owner.
+// The lease which is already held by the owner is returned if the owner locks again, it's released
+// by the first Unlock.
+func (sl *SharedLock) TryLock(action Action) (*Lease, Action, error) {
+	if action == nil || len(action.GetOwnerKey()) == 0 {
+		return nil, nil, ErrNoOwner
+	}
+	lease, ok, err := sl.locker.Acquire(sl.name, action, sl.config.ttl)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, lease, nil
+	}
+
+	sl.lock.Lock()
+	defer sl.lock.Unlock()
+	if held, ok := sl.leases[lease.Owner]; ok && held.Token == lease.Token && held.active() {
+		// the lease is renewed by Acquire, so it keeps the running renewal
+		held.setExpires(lease.Expires())
+		return held, action, nil
+	}
+	lease.locker = sl.locker
+	lease.shared = sl
+	sl.leases[lease.Owner] = lease
+	if sl.config.renew > 0 {
+		go lease.keep(sl.config.ttl, sl.config.renew)
+	}
+	return lease, action, nil
+}
+
+func (sl *SharedLock) forget(lease *Lease) {
+	sl.lock.Lock()
+	defer sl.lock.Unlock()
+	if sl.leases[lease.Owner] == lease {
+		delete(sl.leases, lease.Owner)
+	}
+}
+
+// TryLockContext waits until the lock is acquired or ctx is done, the context error is returned
+// with the last holder if the lock is not acquired.
+func (sl *SharedLock) TryLockContext(ctx context.Context, action Action) (*Lease, Action, error) {
+	ticker := time.NewTicker(sl.config.retry)
+	defer ticker.Stop()
+	for {
+		lease, holder, err := sl.TryLock(action)
+		if lease != nil || err != nil {
+			return lease, holder, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, holder, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// TryLockTimeout waits at most timeout for the lock.
+func (sl *SharedLock) TryLockTimeout(action Action, timeout time.Duration) (*Lease, Action, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return sl.TryLockContext(ctx, action)
+}
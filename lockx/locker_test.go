@@ -0,0 +1,283 @@
+package lockx
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+type testAction struct {
+	owner string
+	code  string
+}
+
+func (ta testAction) GetCode() string {
+	return ta.code
+}
+
+func (ta testAction) GetMessage() string {
+	return ta.code + " by " + ta.owner
+}
+
+func (ta testAction) GetOwnerKey() string {
+	return ta.owner
+}
+
+func testLocker(t *testing.T, locker Locker, expire func()) {
+	alice, bob := testAction{"alice", "export"}, testAction{"bob", "import"}
+	ttl := 50 * time.Millisecond
+
+	lease, ok, err := locker.Acquire("res", alice, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || lease.GetOwnerKey() != "alice" {
+		t.Fatalf("Acquire() of the free lock = %v, %v", lease, ok)
+	}
+	token := lease.Token
+
+	holder, ok, err := locker.Acquire("res", bob, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || holder.GetOwnerKey() != "alice" || holder.GetCode() != "export" || holder.Token != token {
+		t.Errorf("Acquire() of the held lock = %v, %v, want the holder alice", holder, ok)
+	}
+
+	// the owner renews the lock with the same token
+	again, ok, err := locker.Acquire("res", alice, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || again.Token != token {
+		t.Errorf("Acquire() by the owner = %v, %v, want the token %d", again, ok, token)
+	}
+	if _, err = locker.Renew(lease, ttl); err != nil {
+		t.Errorf("Renew() = %v", err)
+	}
+
+	if err = locker.Release(lease); err != nil {
+		t.Fatal(err)
+	}
+	lease, ok, err = locker.Acquire("res", bob, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || lease.Token <= token {
+		t.Fatalf("Acquire() after Release() = %v, %v, want the token above %d", lease, ok, token)
+	}
+	token = lease.Token
+
+	expire()
+	if _, err = locker.Renew(lease, ttl); err != ErrLockLost {
+		t.Errorf("Renew() of the expired lease = %v, want %v", err, ErrLockLost)
+	}
+	next, ok, err := locker.Acquire("res", alice, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || next.Token <= token {
+		t.Errorf("Acquire() after expired = %v, %v, want the token above %d", next, ok, token)
+	}
+
+	// the released lease of the previous holder must not remove the lock
+	if err = locker.Release(lease); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err = locker.Acquire("res", bob, ttl); err != nil || ok {
+		t.Errorf("Acquire() after the stale release = %v, %v, want false", ok, err)
+	}
+}
+
+func TestMemoryLocker(t *testing.T) {
+	testLocker(t, NewMemoryLocker(), func() {
+		time.Sleep(60 * time.Millisecond)
+	})
+}
+
+func TestBuntLocker(t *testing.T) {
+	locker, err := NewBuntLocker(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer locker.Close()
+	testLocker(t, locker, func() {
+		time.Sleep(60 * time.Millisecond)
+	})
+}
+
+func TestRedisLocker(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+	testLocker(t, NewRedisLocker(redis.NewClient(&redis.Options{Addr: mr.Addr()})), func() {
+		mr.FastForward(60 * time.Millisecond)
+	})
+}
+
+func TestSharedLock(t *testing.T) {
+	locker := NewMemoryLocker()
+	sl := NewSharedLock(locker, "report", LockTTL(60*time.Millisecond), RetryInterval(5*time.Millisecond))
+	alice, bob := testAction{"alice", "export"}, testAction{"bob", "import"}
+
+	if _, _, err := sl.TryLock(testAction{}); err != ErrNoOwner {
+		t.Errorf("TryLock() without owner = %v, want %v", err, ErrNoOwner)
+	}
+
+	lease, _, err := sl.TryLock(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease == nil {
+		t.Fatal("TryLock() of the free lock returns nil lease")
+	}
+
+	// the lease is renewed beyond the ttl
+	time.Sleep(150 * time.Millisecond)
+	none, holder, err := sl.TryLockTimeout(bob, 30*time.Millisecond)
+	if none != nil || err == nil {
+		t.Errorf("TryLockTimeout() of the held lock = %v, %v, want the error", none, err)
+	}
+	if holder == nil || holder.GetOwnerKey() != "alice" {
+		t.Errorf("TryLockTimeout() holder = %v, want alice", holder)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = lease.Unlock()
+	}()
+	next, _, err := sl.TryLockTimeout(bob, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == nil {
+		t.Fatal("TryLockTimeout() returns nil lease")
+	}
+	if next.Token <= lease.Token {
+		t.Errorf("next token = %d, want above %d", next.Token, lease.Token)
+	}
+	if err = next.Unlock(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSharedLock_Lost(t *testing.T) {
+	locker := NewMemoryLocker()
+	sl := NewSharedLock(locker, "report", LockTTL(30*time.Millisecond), RenewInterval(10*time.Millisecond))
+	lease, _, err := sl.TryLock(testAction{"alice", "export"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// another replica takes the lock after the lease is released behind the holder
+	if err = locker.Release(lease); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := locker.Acquire("report", testAction{"bob", "import"}, time.Second); !ok {
+		t.Fatal("Acquire() after the release = false")
+	}
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Error("lost lease is not reported")
+	}
+}
+
+func TestResourceLock(t *testing.T) {
+	var rl ResourceLock
+	alice := testAction{"alice", "export"}
+	if ok, _ := rl.TryLock(alice); !ok {
+		t.Fatal("TryLock() of the free lock = false")
+	}
+	if ok, holder := rl.TryLock(testAction{"bob", "import"}); ok || holder != alice {
+		t.Errorf("TryLock() of the held lock = %v, %v, want false, %v", ok, holder, alice)
+	}
+
+	var wg sync.WaitGroup
+	count := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rl.Lock()
+			count++
+			rl.Unlock()
+		}()
+	}
+	rl.Unlock()
+	wg.Wait()
+	if count != 10 {
+		t.Errorf("count = %d, want 10", count)
+	}
+	if rl.IsLocked() {
+		t.Error("IsLocked() after all the unlocks = true")
+	}
+}
+
+// flakyLocker fails the renewal with the transient error until it's healed.
+type flakyLocker struct {
+	*MemoryLocker
+	failures int32
+	renewals int32
+}
+
+func (fl *flakyLocker) Renew(lease *Lease, ttl time.Duration) (time.Time, error) {
+	atomic.AddInt32(&fl.renewals, 1)
+	if atomic.AddInt32(&fl.failures, -1) >= 0 {
+		return time.Time{}, errors.New("connection refused")
+	}
+	return fl.MemoryLocker.Renew(lease, ttl)
+}
+
+func TestSharedLock_RenewRetry(t *testing.T) {
+	locker := &flakyLocker{MemoryLocker: NewMemoryLocker(), failures: 2}
+	sl := NewSharedLock(locker, "report", LockTTL(90*time.Millisecond), RenewInterval(20*time.Millisecond))
+	alice := testAction{"alice", "export"}
+	lease, _, err := sl.TryLock(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lease.Unlock()
+
+	// the transient failures are retried before the lease is expired
+	time.Sleep(150 * time.Millisecond)
+	select {
+	case <-lease.Lost():
+		t.Fatal("lease is lost by the transient failures")
+	default:
+	}
+
+	// the owner locks again without starting another renewal
+	again, _, err := sl.TryLock(alice)
+	if err != nil || again != lease {
+		t.Fatalf("TryLock() by the owner = %p, %v, want the held lease %p", again, err, lease)
+	}
+	before := atomic.LoadInt32(&locker.renewals)
+	time.Sleep(100 * time.Millisecond)
+	if renewals := atomic.LoadInt32(&locker.renewals) - before; renewals > 6 {
+		t.Errorf("lease is renewed %d times in 100ms, the renewal is duplicated", renewals)
+	}
+}
+
+func TestSharedLock_RenewExpired(t *testing.T) {
+	locker := &flakyLocker{MemoryLocker: NewMemoryLocker(), failures: 100}
+	sl := NewSharedLock(locker, "report", LockTTL(50*time.Millisecond), RenewInterval(20*time.Millisecond))
+	lease, _, err := sl.TryLock(testAction{"alice", "export"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lease.Lost():
+		if time.Now().Before(lease.Expires()) {
+			t.Error("lease is lost before it's expired")
+		}
+	case <-time.After(time.Second):
+		t.Error("expired lease is not reported")
+	}
+}
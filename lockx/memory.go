@@ -0,0 +1,83 @@
+package lockx
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryLock struct {
+	owner   string
+	code    string
+	message string
+	token   uint64
+	expires time.Time
+}
+
+func (ml memoryLock) lease(name string) *Lease {
+	return NewLease(name, ml, ml.token, ml.expires)
+}
+
+func (ml memoryLock) GetCode() string {
+	return ml.code
+}
+
+func (ml memoryLock) GetMessage() string {
+	return ml.message
+}
+
+func (ml memoryLock) GetOwnerKey() string {
+	return ml.owner
+}
+
+// MemoryLocker keeps the locks in memory, it only works within one process.
+type MemoryLocker struct {
+	locks  map[string]memoryLock
+	tokens map[string]uint64
+	lock   sync.Mutex
+}
+
+//export
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: make(map[string]memoryLock), tokens: make(map[string]uint64)}
+}
+
+func (ml *MemoryLocker) Acquire(name string, action Action, ttl time.Duration) (*Lease, bool, error) {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+	now := time.Now()
+	held, ok := ml.locks[name]
+	if ok && held.expires.After(now) {
+		if held.owner != action.GetOwnerKey() {
+			return held.lease(name), false, nil
+		}
+	} else {
+		ml.tokens[name]++
+		held.token = ml.tokens[name]
+	}
+	held.owner, held.code, held.message = action.GetOwnerKey(), action.GetCode(), action.GetMessage()
+	held.expires = now.Add(ttl)
+	ml.locks[name] = held
+	return held.lease(name), true, nil
+}
+
+func (ml *MemoryLocker) Renew(lease *Lease, ttl time.Duration) (time.Time, error) {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+	now := time.Now()
+	held, ok := ml.locks[lease.Name]
+	if !ok || held.owner != lease.Owner || held.token != lease.Token || !held.expires.After(now) {
+		return time.Time{}, ErrLockLost
+	}
+	held.expires = now.Add(ttl)
+	ml.locks[lease.Name] = held
+	return held.expires, nil
+}
+
+func (ml *MemoryLocker) Release(lease *Lease) error {
+	ml.lock.Lock()
+	defer ml.lock.Unlock()
+	if held, ok := ml.locks[lease.Name]; ok && held.owner == lease.Owner && held.token == lease.Token {
+		delete(ml.locks, lease.Name)
+	}
+	return nil
+}
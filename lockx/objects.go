@@ -1,39 +1,123 @@
 package lockx
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
+// ResourceLock is the exclusive lock within the process, the holder is described by the Action.
 type ResourceLock struct {
-	sync.Mutex
-	tryLock sync.Mutex
-	locked  bool
-	action  Action
+	name     string
+	lock     sync.Mutex
+	cond     *sync.Cond
+	locked   bool
+	action   Action
+	acquired time.Time
+	waiters  int
 }
 
-func (rl *ResourceLock) TryLock(action Action) (bool, Action) {
-	if rl.locked {
-		return false, rl.action
+func (rl *ResourceLock) wait() {
+	if rl.cond == nil {
+		rl.cond = sync.NewCond(&rl.lock)
+	}
+	rl.cond.Wait()
+}
+
+// Lock waits until the lock is free, the holder has no action, see LockAction.
+func (rl *ResourceLock) Lock() {
+	rl.LockAction(nil)
+}
+
+// LockAction waits until the lock is free and holds it for the action.
+func (rl *ResourceLock) LockAction(action Action) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	rl.waiters++
+	for rl.locked {
+		rl.wait()
 	}
-	rl.tryLock.Lock()
-	defer rl.tryLock.Unlock()
+	rl.waiters--
+	rl.hold(action)
+}
+
+// TryLock gets the lock without waiting, the action of the holder is returned if it's locked.
+func (rl *ResourceLock) TryLock(action Action) (bool, Action) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
 	if rl.locked {
 		return false, rl.action
 	}
-	rl.Mutex.Lock()
+	rl.hold(action)
+	return true, action
+}
+
+func (rl *ResourceLock) hold(action Action) {
 	rl.locked = true
 	rl.action = action
-	return true, action
+	rl.acquired = time.Now()
 }
 
+// Unlock releases the lock regardless of the holder. The holder whose lock may be released by
+// Registry.ForceRelease should use UnlockAction, so its late unlock doesn't release the next holder.
 func (rl *ResourceLock) Unlock() {
-	rl.Mutex.Unlock()
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	if !rl.locked {
+		panic("lockx: unlock of unlocked ResourceLock")
+	}
+	rl.release()
+}
+
+// UnlockAction releases the lock only if it's held by the owner of the action, false is returned
+// if it's not, e.g. the lock has been released by force and taken by another owner.
+func (rl *ResourceLock) UnlockAction(action Action) bool {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	if !rl.heldBy(action) {
+		return false
+	}
+	rl.release()
+	return true
+}
+
+func (rl *ResourceLock) heldBy(action Action) bool {
+	if !rl.locked || rl.action == nil || action == nil {
+		return false
+	}
+	owner := action.GetOwnerKey()
+	return len(owner) > 0 && rl.action.GetOwnerKey() == owner
+}
+
+func (rl *ResourceLock) release() {
 	rl.locked = false
 	rl.action = nil
+	rl.acquired = time.Time{}
+	if rl.cond != nil {
+		rl.cond.Signal()
+	}
 }
 
 func (rl *ResourceLock) IsLocked() bool {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
 	return rl.locked
 }
 
+// Info returns the snapshot of the lock and its holder.
+func (rl *ResourceLock) Info() LockInfo {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	return rl.info()
+}
+
+func (rl *ResourceLock) info() LockInfo {
+	info := LockInfo{Name: rl.name, Locked: rl.locked, Acquired: rl.acquired, Waiters: rl.waiters}
+	if rl.action != nil {
+		info.Owner, info.Code, info.Message = rl.action.GetOwnerKey(), rl.action.GetCode(), rl.action.GetMessage()
+	}
+	return info
+}
+
 type Action interface {
 	GetCode() string
 	GetMessage() string
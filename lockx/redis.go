@@ -0,0 +1,127 @@
+package lockx
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	redisLockPrefix  = "lockx:lock:"
+	redisTokenPrefix = "lockx:token:"
+)
+
+// KEYS: lock, token; ARGV: owner, code, message, ttl in milliseconds
+var acquireScript = redis.NewScript(`
+local owner = redis.call('HGET', KEYS[1], 'owner')
+if owner and owner ~= ARGV[1] then
+	local held = redis.call('HMGET', KEYS[1], 'owner', 'code', 'message', 'token')
+	return {0, held[1], held[2], held[3], held[4], redis.call('PTTL', KEYS[1])}
+end
+local token
+if owner then
+	token = redis.call('HGET', KEYS[1], 'token')
+else
+	token = tostring(redis.call('INCR', KEYS[2]))
+end
+redis.call('HMSET', KEYS[1], 'owner', ARGV[1], 'code', ARGV[2], 'message', ARGV[3], 'token', token)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {1, ARGV[1], ARGV[2], ARGV[3], token, tonumber(ARGV[4])}
+`)
+
+// KEYS: lock; ARGV: owner, token, ttl in milliseconds
+var renewScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') == ARGV[1] and redis.call('HGET', KEYS[1], 'token') == ARGV[2] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return 0
+`)
+
+// KEYS: lock; ARGV: owner, token
+var releaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'owner') == ARGV[1] and redis.call('HGET', KEYS[1], 'token') == ARGV[2] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+type redisHolder struct {
+	owner   string
+	code    string
+	message string
+}
+
+func (rh redisHolder) GetCode() string {
+	return rh.code
+}
+
+func (rh redisHolder) GetMessage() string {
+	return rh.message
+}
+
+func (rh redisHolder) GetOwnerKey() string {
+	return rh.owner
+}
+
+// RedisLocker keeps the locks in redis, or any server speaking the redis protocol with the lua scripting,
+// the changes of each lock are made atomically by the scripts.
+type RedisLocker struct {
+	client redis.UniversalClient
+}
+
+//export
+func NewRedisLocker(client redis.UniversalClient) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+func (rl *RedisLocker) GetClient() redis.UniversalClient {
+	return rl.client
+}
+
+func (rl *RedisLocker) Acquire(name string, action Action, ttl time.Duration) (*Lease, bool, error) {
+	res, err := acquireScript.Run(rl.client, []string{redisLockPrefix + name, redisTokenPrefix + name},
+		action.GetOwnerKey(), action.GetCode(), action.GetMessage(), int64(ttl/time.Millisecond)).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 6 {
+		return nil, false, fmt.Errorf("unexpected reply of lock %s : %v", name, res)
+	}
+	strs := make([]string, len(values))
+	for i, v := range values {
+		if v != nil {
+			strs[i] = fmt.Sprint(v)
+		}
+	}
+	token, err := strconv.ParseUint(strs[4], 10, 64)
+	if err != nil {
+		return nil, false, err
+	}
+	remains, err := strconv.ParseInt(strs[5], 10, 64)
+	if err != nil {
+		return nil, false, err
+	}
+	holder := redisHolder{owner: strs[1], code: strs[2], message: strs[3]}
+	lease := NewLease(name, holder, token, time.Now().Add(time.Duration(remains)*time.Millisecond))
+	return lease, strs[0] == "1", nil
+}
+
+func (rl *RedisLocker) Renew(lease *Lease, ttl time.Duration) (time.Time, error) {
+	ok, err := renewScript.Run(rl.client, []string{redisLockPrefix + lease.Name},
+		lease.Owner, strconv.FormatUint(lease.Token, 10), int64(ttl/time.Millisecond)).Int64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ok == 0 {
+		return time.Time{}, ErrLockLost
+	}
+	return time.Now().Add(ttl), nil
+}
+
+func (rl *RedisLocker) Release(lease *Lease) error {
+	return releaseScript.Run(rl.client, []string{redisLockPrefix + lease.Name},
+		lease.Owner, strconv.FormatUint(lease.Token, 10)).Err()
+}
@@ -0,0 +1,95 @@
+package lockx
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrNotLocked = errors.New("resource is not locked")
+
+// LockInfo describes the lock and its holder, the holder fields are empty if it's locked without the action.
+type LockInfo struct {
+	Name     string    `json:"name"`
+	Locked   bool      `json:"locked"`
+	Owner    string    `json:"owner,omitempty"`
+	Code     string    `json:"code,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Acquired time.Time `json:"acquired"`
+	Waiters  int       `json:"waiters"`
+}
+
+// Registry keeps the resource locks by name, so that the held locks can be inspected in one place.
+type Registry struct {
+	locks map[string]*ResourceLock
+	lock  sync.RWMutex
+}
+
+//export
+func NewRegistry() *Registry {
+	return &Registry{locks: make(map[string]*ResourceLock)}
+}
+
+// Get returns the lock of the name, the lock is created on the first call.
+func (r *Registry) Get(name string) *ResourceLock {
+	r.lock.RLock()
+	rl, ok := r.locks[name]
+	r.lock.RUnlock()
+	if ok {
+		return rl
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if rl, ok = r.locks[name]; !ok {
+		rl = &ResourceLock{name: name}
+		r.locks[name] = rl
+	}
+	return rl
+}
+
+// Lookup returns the lock of the name if it's created.
+func (r *Registry) Lookup(name string) (*ResourceLock, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	rl, ok := r.locks[name]
+	return rl, ok
+}
+
+// Held returns the locks which are held or waited, ordered by name.
+func (r *Registry) Held() []LockInfo {
+	r.lock.RLock()
+	infos := make([]LockInfo, 0, len(r.locks))
+	for _, rl := range r.locks {
+		if info := rl.Info(); info.Locked || info.Waiters > 0 {
+			infos = append(infos, info)
+		}
+	}
+	r.lock.RUnlock()
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// ForceRelease unlocks the lock regardless of the holder, it's meant for the administrator
+// to recover from the holder which never unlocks. Only the lock held with the owner key of
+// the action is released, ErrNoOwner is returned otherwise, and the holder should unlock
+// with UnlockAction, so its late unlock is ignored once the lock is taken by another owner.
+func (r *Registry) ForceRelease(name string) (LockInfo, error) {
+	rl, ok := r.Lookup(name)
+	if !ok {
+		return LockInfo{Name: name}, ErrNotLocked
+	}
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	info := rl.info()
+	if !rl.locked {
+		return info, ErrNotLocked
+	}
+	if len(info.Owner) == 0 {
+		return info, ErrNoOwner
+	}
+	rl.release()
+	return info, nil
+}
@@ -0,0 +1,80 @@
+package lockx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	rl := r.Get("report")
+	if rl != r.Get("report") {
+		t.Error("Get() returns another lock of the same name")
+	}
+	r.Get("idle")
+
+	alice, bob := testAction{"alice", "export"}, testAction{"bob", "import"}
+	if ok, _ := rl.TryLock(alice); !ok {
+		t.Fatal("TryLock() of the free lock = false")
+	}
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		rl.LockAction(bob)
+		close(locked)
+		<-release
+		rl.UnlockAction(bob)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	held := r.Held()
+	if len(held) != 1 {
+		t.Fatalf("Held() = %v, want the report lock only", held)
+	}
+	if h := held[0]; h.Name != "report" || h.Owner != "alice" || h.Code != "export" || h.Waiters != 1 || h.Acquired.IsZero() {
+		t.Errorf("Held()[0] = %+v", h)
+	}
+
+	info, err := r.ForceRelease("report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Owner != "alice" {
+		t.Errorf("ForceRelease() owner = %s, want alice", info.Owner)
+	}
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("waiter is not woken up by the force release")
+	}
+	if owner := rl.Info().Owner; owner != "bob" {
+		t.Errorf("Info().Owner = %s, want bob", owner)
+	}
+	// the late unlock of the previous holder doesn't release the next holder
+	if rl.UnlockAction(alice) {
+		t.Error("UnlockAction() of the released holder = true")
+	}
+	if !rl.IsLocked() {
+		t.Error("IsLocked() = false, the next holder is released")
+	}
+	close(release)
+	time.Sleep(20 * time.Millisecond)
+	if rl.IsLocked() {
+		t.Error("IsLocked() after UnlockAction() = true")
+	}
+
+	if _, err = r.ForceRelease("report"); err != ErrNotLocked {
+		t.Errorf("ForceRelease() of the free lock = %v, want %v", err, ErrNotLocked)
+	}
+	rl.Lock()
+	if _, err = r.ForceRelease("report"); err != ErrNoOwner {
+		t.Errorf("ForceRelease() of the owner-less holder = %v, want %v", err, ErrNoOwner)
+	}
+	rl.Unlock()
+	if _, err = r.ForceRelease("unknown"); err != ErrNotLocked {
+		t.Errorf("ForceRelease() of the unknown lock = %v, want %v", err, ErrNotLocked)
+	}
+	if held := r.Held(); len(held) != 0 {
+		t.Errorf("Held() = %v, want empty", held)
+	}
+}
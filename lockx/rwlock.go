@@ -0,0 +1,214 @@
+package lockx
+
+import (
+	"context"
+	"sync"
+)
+
+func ownerKey(action Action) string {
+	if action == nil {
+		return ""
+	}
+	return action.GetOwnerKey()
+}
+
+type readHold struct {
+	action Action
+	count  int
+}
+
+// ResourceRWLock is the read/write lock within the process, many readers or one writer can hold it,
+// the holders are described by the Action. The readers are tracked by the owner key of the action.
+// Once a writer is waiting, the new readers of other owners are blocked, so the writer is not starved.
+//
+// In the reentrant mode the owner of the write lock can lock it again and take the read lock as well,
+// and the only reader can upgrade to the write lock, each hold must be released by its own unlock.
+type ResourceRWLock struct {
+	lock      sync.Mutex
+	reentrant bool
+	writer    Action
+	writes    int
+	readers   map[string]*readHold
+	reads     int
+	waiting   map[string]*readHold // the writers waiting in LockContext by the owner key
+	changed   chan struct{}
+}
+
+//export
+// NewReentrantRWLock returns the lock in the reentrant mode, the zero value of ResourceRWLock is not reentrant.
+func NewReentrantRWLock() *ResourceRWLock {
+	return &ResourceRWLock{reentrant: true}
+}
+
+func (rw *ResourceRWLock) ownedBy(owner string) bool {
+	return rw.reentrant && len(owner) > 0
+}
+
+// TryLock gets the write lock without waiting, the action of a holder is returned if it's held by others.
+func (rw *ResourceRWLock) TryLock(action Action) (bool, Action) {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	owner := ownerKey(action)
+	if rw.writes > 0 {
+		if rw.ownedBy(owner) && ownerKey(rw.writer) == owner {
+			rw.writes++
+			return true, action
+		}
+		return false, rw.writer
+	}
+	for key, hold := range rw.readers {
+		if !rw.ownedBy(owner) || key != owner {
+			return false, hold.action
+		}
+	}
+	rw.writer = action
+	rw.writes = 1
+	return true, action
+}
+
+// TryRLock gets the read lock without waiting, the action of the writer is returned if it's held
+// or waited by others. In the reentrant mode the owner which already reads is not blocked by the waiting writer.
+func (rw *ResourceRWLock) TryRLock(action Action) (bool, Action) {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	owner := ownerKey(action)
+	if rw.writes > 0 && !(rw.ownedBy(owner) && ownerKey(rw.writer) == owner) {
+		return false, rw.writer
+	}
+	if _, reading := rw.readers[owner]; !(reading && rw.ownedBy(owner)) {
+		for key, waiter := range rw.waiting {
+			if !rw.ownedBy(owner) || key != owner {
+				return false, waiter.action
+			}
+		}
+	}
+	if rw.readers == nil {
+		rw.readers = make(map[string]*readHold)
+	}
+	hold, ok := rw.readers[owner]
+	if !ok {
+		hold = &readHold{action: action}
+		rw.readers[owner] = hold
+	}
+	hold.count++
+	rw.reads++
+	return true, action
+}
+
+// Unlock releases one hold of the write lock taken by the owner of the action.
+func (rw *ResourceRWLock) Unlock(action Action) {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	if rw.writes == 0 || ownerKey(rw.writer) != ownerKey(action) {
+		panic("lockx: unlock of unlocked ResourceRWLock")
+	}
+	rw.writes--
+	if rw.writes == 0 {
+		rw.writer = nil
+		rw.notify()
+	}
+}
+
+// RUnlock releases one hold of the read lock taken by the owner of the action.
+func (rw *ResourceRWLock) RUnlock(action Action) {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	owner := ownerKey(action)
+	hold, ok := rw.readers[owner]
+	if !ok {
+		panic("lockx: runlock of unlocked ResourceRWLock")
+	}
+	hold.count--
+	rw.reads--
+	if hold.count == 0 {
+		delete(rw.readers, owner)
+		rw.notify()
+	}
+}
+
+// notify wakes up the waiters, it's called with the lock held.
+func (rw *ResourceRWLock) notify() {
+	if rw.changed != nil {
+		close(rw.changed)
+		rw.changed = nil
+	}
+}
+
+func (rw *ResourceRWLock) waitChan() <-chan struct{} {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	if rw.changed == nil {
+		rw.changed = make(chan struct{})
+	}
+	return rw.changed
+}
+
+func (rw *ResourceRWLock) wait(ctx context.Context, try func(Action) (bool, Action), action Action) error {
+	for {
+		// the channel is taken before trying, so that the release in between is not missed
+		changed := rw.waitChan()
+		if ok, _ := try(action); ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// LockContext waits for the write lock until it's acquired or ctx is done,
+// the new readers of other owners are blocked while it's waiting.
+func (rw *ResourceRWLock) LockContext(ctx context.Context, action Action) error {
+	owner := ownerKey(action)
+	rw.lock.Lock()
+	if rw.waiting == nil {
+		rw.waiting = make(map[string]*readHold)
+	}
+	waiter, ok := rw.waiting[owner]
+	if !ok {
+		waiter = &readHold{action: action}
+		rw.waiting[owner] = waiter
+	}
+	waiter.count++
+	rw.lock.Unlock()
+
+	defer func() {
+		rw.lock.Lock()
+		defer rw.lock.Unlock()
+		if waiter.count--; waiter.count == 0 {
+			delete(rw.waiting, owner)
+			// the blocked readers go on if the writer gives up
+			rw.notify()
+		}
+	}()
+	return rw.wait(ctx, rw.TryLock, action)
+}
+
+// RLockContext waits for the read lock until it's acquired or ctx is done.
+func (rw *ResourceRWLock) RLockContext(ctx context.Context, action Action) error {
+	return rw.wait(ctx, rw.TryRLock, action)
+}
+
+func (rw *ResourceRWLock) Lock(action Action) {
+	_ = rw.LockContext(context.Background(), action)
+}
+
+func (rw *ResourceRWLock) RLock(action Action) {
+	_ = rw.RLockContext(context.Background(), action)
+}
+
+// IsLocked reports whether the write lock is held.
+func (rw *ResourceRWLock) IsLocked() bool {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	return rw.writes > 0
+}
+
+// Readers returns the count of the read holds.
+func (rw *ResourceRWLock) Readers() int {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	return rw.reads
+}
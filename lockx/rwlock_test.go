@@ -0,0 +1,201 @@
+package lockx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestResourceRWLock(t *testing.T) {
+	var rw ResourceRWLock
+	alice, bob := testAction{"alice", "report"}, testAction{"bob", "import"}
+
+	if ok, _ := rw.TryRLock(alice); !ok {
+		t.Fatal("TryRLock() of the free lock = false")
+	}
+	if ok, _ := rw.TryRLock(bob); !ok {
+		t.Fatal("TryRLock() of the second reader = false")
+	}
+	if readers := rw.Readers(); readers != 2 {
+		t.Errorf("Readers() = %d, want 2", readers)
+	}
+	if ok, holder := rw.TryLock(bob); ok || holder == nil {
+		t.Errorf("TryLock() with readers = %v, %v, want false and a holder", ok, holder)
+	}
+
+	rw.RUnlock(alice)
+	rw.RUnlock(bob)
+	if ok, _ := rw.TryLock(bob); !ok {
+		t.Fatal("TryLock() of the free lock = false")
+	}
+	if ok, holder := rw.TryRLock(alice); ok || holder != bob {
+		t.Errorf("TryRLock() with the writer = %v, %v, want false, %v", ok, holder, bob)
+	}
+	// not reentrant
+	if ok, holder := rw.TryLock(bob); ok || holder != bob {
+		t.Errorf("TryLock() of the writer again = %v, %v, want false, %v", ok, holder, bob)
+	}
+	rw.Unlock(bob)
+	if rw.IsLocked() {
+		t.Error("IsLocked() after Unlock() = true")
+	}
+}
+
+func TestResourceRWLock_Reentrant(t *testing.T) {
+	rw := NewReentrantRWLock()
+	alice, bob := testAction{"alice", "report"}, testAction{"bob", "import"}
+
+	if ok, _ := rw.TryLock(alice); !ok {
+		t.Fatal("TryLock() of the free lock = false")
+	}
+	if ok, _ := rw.TryLock(alice); !ok {
+		t.Error("TryLock() of the writer again = false")
+	}
+	if ok, _ := rw.TryRLock(alice); !ok {
+		t.Error("TryRLock() of the writer = false")
+	}
+	if ok, holder := rw.TryRLock(bob); ok || holder != alice {
+		t.Errorf("TryRLock() of others = %v, %v, want false, %v", ok, holder, alice)
+	}
+
+	rw.RUnlock(alice)
+	rw.Unlock(alice)
+	if !rw.IsLocked() {
+		t.Error("IsLocked() with a remaining write hold = false")
+	}
+	rw.Unlock(alice)
+	if rw.IsLocked() {
+		t.Error("IsLocked() after all the unlocks = true")
+	}
+
+	// the only reader upgrades to the writer
+	if ok, _ := rw.TryRLock(alice); !ok {
+		t.Fatal("TryRLock() of the free lock = false")
+	}
+	if ok, _ := rw.TryLock(alice); !ok {
+		t.Error("TryLock() of the only reader = false")
+	}
+	rw.Unlock(alice)
+	if ok, _ := rw.TryRLock(bob); !ok {
+		t.Error("TryRLock() with another reader = false")
+	}
+	if ok, holder := rw.TryLock(alice); ok || holder != bob {
+		t.Errorf("TryLock() with another reader = %v, %v, want false, %v", ok, holder, bob)
+	}
+}
+
+func TestResourceRWLock_Context(t *testing.T) {
+	var rw ResourceRWLock
+	alice, bob := testAction{"alice", "report"}, testAction{"bob", "import"}
+	rw.RLock(alice)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := rw.LockContext(ctx, bob); err != context.DeadlineExceeded {
+		t.Errorf("LockContext() = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rw.RUnlock(alice)
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := rw.LockContext(ctx, bob); err != nil {
+		t.Fatal(err)
+	}
+	if !rw.IsLocked() {
+		t.Error("IsLocked() after LockContext() = false")
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- rw.RLockContext(ctx, alice)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	rw.Unlock(bob)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if readers := rw.Readers(); readers != 1 {
+		t.Errorf("Readers() = %d, want 1", readers)
+	}
+}
+
+func TestResourceRWLock_WriterPreference(t *testing.T) {
+	rw := NewReentrantRWLock()
+	alice, bob, carol := testAction{"alice", "report"}, testAction{"bob", "import"}, testAction{"carol", "report"}
+	rw.RLock(alice)
+
+	locked := make(chan error)
+	go func() {
+		locked <- rw.LockContext(context.Background(), bob)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	// the new reader waits behind the writer, the current reader still reads again
+	if ok, holder := rw.TryRLock(carol); ok || holder != bob {
+		t.Errorf("TryRLock() with a waiting writer = %v, %v, want false, %v", ok, holder, bob)
+	}
+	if ok, _ := rw.TryRLock(alice); !ok {
+		t.Error("TryRLock() of the current reader is blocked by the waiting writer")
+	}
+	rw.RUnlock(alice)
+	rw.RUnlock(alice)
+	if err := <-locked; err != nil {
+		t.Fatal(err)
+	}
+	rw.Unlock(bob)
+
+	// the readers go on once the waiting writer gives up
+	rw.RLock(alice)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := rw.LockContext(ctx, bob); err != context.DeadlineExceeded {
+		t.Errorf("LockContext() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if ok, _ := rw.TryRLock(carol); !ok {
+		t.Error("TryRLock() is blocked after the writer gives up")
+	}
+}
+
+func TestResourceRWLock_WriterPreferenceNotReentrant(t *testing.T) {
+	var rw ResourceRWLock
+	alice, bob := testAction{"alice", "report"}, testAction{"bob", "import"}
+	rw.RLock(alice)
+	rw.RLock(nil)
+
+	locked := make(chan error)
+	go func() {
+		locked <- rw.LockContext(context.Background(), bob)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	// without the reentrant mode, the current readers can't read again once the writer waits
+	if ok, holder := rw.TryRLock(alice); ok || holder != bob {
+		t.Errorf("TryRLock() of the current reader = %v, %v, want false, %v", ok, holder, bob)
+	}
+	if ok, holder := rw.TryRLock(nil); ok || holder != bob {
+		t.Errorf("TryRLock() of the nil reader = %v, %v, want false, %v", ok, holder, bob)
+	}
+	rw.RUnlock(alice)
+	rw.RUnlock(nil)
+	if err := <-locked; err != nil {
+		t.Fatal(err)
+	}
+	rw.Unlock(bob)
+
+	// the nil readers never pass the waiting writer in the reentrant mode either
+	reentrant := NewReentrantRWLock()
+	reentrant.RLock(nil)
+	go func() {
+		locked <- reentrant.LockContext(context.Background(), bob)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if ok, holder := reentrant.TryRLock(nil); ok || holder != bob {
+		t.Errorf("TryRLock() of the nil reader in the reentrant mode = %v, %v, want false, %v", ok, holder, bob)
+	}
+	reentrant.RUnlock(nil)
+	if err := <-locked; err != nil {
+		t.Fatal(err)
+	}
+	reentrant.Unlock(bob)
+}
@@ -127,3 +127,23 @@ func IsValueNil(v interface{}) bool {
 		return false
 	}
 }
+
+// AssignValue stores value into the variable target points to,
+// a pointer value is dereferenced if its element is assignable to the target.
+func AssignValue(target interface{}, value interface{}) bool {
+	tv := reflect.ValueOf(target)
+	if tv.Kind() != reflect.Ptr || tv.IsNil() || value == nil {
+		return false
+	}
+	tv = tv.Elem()
+	vv := reflect.ValueOf(value)
+	if vv.Type().AssignableTo(tv.Type()) {
+		tv.Set(vv)
+		return true
+	}
+	if vv.Kind() == reflect.Ptr && !vv.IsNil() && vv.Elem().Type().AssignableTo(tv.Type()) {
+		tv.Set(vv.Elem())
+		return true
+	}
+	return false
+}
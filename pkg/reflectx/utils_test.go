@@ -205,3 +205,35 @@ func returnNilStruct() interface{} {
 
 	return a
 }
+
+func TestAssignValue(t *testing.T) {
+	var i int
+	var s TestStruct
+	var sp *TestStruct
+	var any interface{}
+	tests := []struct {
+		name   string
+		target interface{}
+		value  interface{}
+		want   bool
+	}{
+		{"int", &i, 10, true},
+		{"Struct", &s, TestStruct{A: 1}, true},
+		{"Struct from pointer", &s, &TestStruct{A: 2}, true},
+		{"Pointer", &sp, &TestStruct{A: 3}, true},
+		{"Interface", &any, "value", true},
+		{"Mismatched type", &i, "value", false},
+		{"Not a pointer", i, 10, false},
+		{"Nil value", &i, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AssignValue(tt.target, tt.value); got != tt.want {
+				t.Errorf("AssignValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if i != 10 || s.A != 2 || sp.A != 3 || any != "value" {
+		t.Errorf("AssignValue() assigned %v %v %v %v", i, s, sp, any)
+	}
+}
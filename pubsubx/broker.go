@@ -0,0 +1,174 @@
+package pubsubx
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fidelfly/gox/logx"
+)
+
+type subscription struct {
+	ch      Subscriber
+	policy  OverflowPolicy
+	payload bool
+	topics  map[string]struct{}
+	cancel  context.CancelFunc
+
+	// the lock keeps the channel from being closed during sending
+	lock   sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// send delivers the envelope according to the overflow policy when the subscriber is full,
+// nothing is sent once the subscriber is closed.
+func (s *subscription) send(env *Envelope) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return
+	}
+	var msg interface{} = env
+	if s.payload && len(env.Header(HeaderReplyTo)) == 0 {
+		msg = env.Payload
+	}
+	switch s.policy {
+	case OverflowDropNewest:
+		select {
+		case s.ch <- msg:
+		default:
+			logx.Warnf("subscriber of %s is full, message %s is dropped", env.Topic, env.ID)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.ch <- msg:
+				return
+			default:
+			}
+			select {
+			case old := <-s.ch:
+				if oldEnv, ok := old.(*Envelope); ok {
+					logx.Warnf("subscriber of %s is full, message %s is dropped", oldEnv.Topic, oldEnv.ID)
+				} else {
+					logx.Warnf("subscriber of %s is full, the oldest message is dropped", env.Topic)
+				}
+			default:
+			}
+		}
+	default:
+		select {
+		case s.ch <- msg:
+		case <-s.done:
+		}
+	}
+}
+
+// close wakes up the blocked senders before closing the channel, it's called only once by the broker.
+func (s *subscription) close() {
+	close(s.done)
+	s.lock.Lock()
+	s.closed = true
+	close(s.ch)
+	s.lock.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// broker dispatches the messages to the subscribers whose topics or patterns match.
+type broker struct {
+	capacity int
+	trie     *topicTrie
+	subs     map[Subscriber]*subscription
+	lock     sync.RWMutex
+}
+
+func newBroker(capacity int) *broker {
+	return &broker{
+		capacity: capacity,
+		trie:     newTopicTrie(),
+		subs:     make(map[Subscriber]*subscription),
+	}
+}
+
+func (b *broker) sub(config subscribeConfig, cancel context.CancelFunc, topics ...string) Subscriber {
+	s := &subscription{
+		ch:      make(Subscriber, config.capacity),
+		policy:  config.overflow,
+		payload: config.payload,
+		topics:  make(map[string]struct{}),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	b.lock.Lock()
+	b.subs[s.ch] = s
+	b.lock.Unlock()
+	b.addSub(s.ch, topics...)
+	return s.ch
+}
+
+func (b *broker) addSub(sub Subscriber, topics ...string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	s, ok := b.subs[sub]
+	if !ok {
+		return false
+	}
+	for _, topic := range topics {
+		if _, ok := s.topics[topic]; !ok {
+			s.topics[topic] = struct{}{}
+			b.trie.add(topic, sub)
+		}
+	}
+	return true
+}
+
+// unsub removes the topics or patterns exactly as they're subscribed, all of them are removed if
+// no topic is specified. The subscriber is closed once it has no topic.
+func (b *broker) unsub(sub Subscriber, topics ...string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	s, ok := b.subs[sub]
+	if !ok {
+		return
+	}
+	if len(topics) == 0 {
+		for topic := range s.topics {
+			topics = append(topics, topic)
+		}
+	}
+	for _, topic := range topics {
+		if _, ok := s.topics[topic]; ok {
+			delete(s.topics, topic)
+			b.trie.remove(topic, sub)
+		}
+	}
+	if len(s.topics) == 0 {
+		delete(b.subs, sub)
+		s.close()
+	}
+}
+
+// count returns how many subscribers match the topic.
+func (b *broker) count(topic string) int {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return len(b.trie.match(topic))
+}
+
+// pub sends the envelope without holding the lock of the broker, so the blocked sending doesn't stop
+// the subscribers from being added or removed.
+func (b *broker) pub(env *Envelope) {
+	b.lock.RLock()
+	var subs []*subscription
+	for _, sub := range b.trie.match(env.Topic) {
+		if s, ok := b.subs[sub]; ok {
+			subs = append(subs, s)
+		}
+	}
+	b.lock.RUnlock()
+	for _, s := range subs {
+		s.send(env)
+	}
+}
@@ -0,0 +1,596 @@
+package pubsubx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/buntdb"
+
+	"github.com/fidelfly/gox/logx"
+)
+
+var ErrNotDurable = errors.New("pubsub is not in durable mode")
+
+const (
+	defaultMaxRetries      = 3
+	defaultRetryDelay      = time.Second
+	DefaultDeadLetterTopic = "pubsubx.deadletter"
+
+	keyPrefix       = "pubsubx:"
+	msgKeyPrefix    = keyPrefix + "msg:"
+	seqKeyPrefix    = keyPrefix + "seq:"
+	offsetKeyPrefix = keyPrefix + "offset:"
+	retryKeyPrefix  = keyPrefix + "retry:"
+	offsetDigits    = 20
+)
+
+// storedMessage is the persisted form of the message published in durable mode.
+type storedMessage struct {
+	ID        string            `json:"id"`
+	Topic     string            `json:"topic"`
+	Offset    uint64            `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Payload   json.RawMessage   `json:"payload"`
+}
+
+// DeadLetter is published to the dead-letter topic for the message which can't be consumed.
+type DeadLetter struct {
+	ID       string          `json:"id"`
+	Topic    string          `json:"topic"`
+	Offset   uint64          `json:"offset"`
+	Consumer string          `json:"consumer"`
+	Attempts int             `json:"attempts"`
+	Reason   string          `json:"reason"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+type outcome int
+
+const (
+	outcomeNone outcome = iota
+	outcomeAck
+	outcomeReject
+	outcomeRedeliver
+)
+
+// Delivery is the durable message delivered to the consumer. The message is acknowledged if the handler
+// returns nil, and redelivered if the handler returns an error, unless the consumer decides it explicitly.
+type Delivery struct {
+	ID        string
+	Topic     string
+	Offset    uint64
+	Timestamp time.Time
+	Headers   map[string]string
+	Payload   json.RawMessage
+	Consumer  string
+	Attempts  int // starts from 1
+
+	outcome outcome
+	reason  error
+}
+
+// Decode unmarshals the payload into v.
+func (d *Delivery) Decode(v interface{}) error {
+	return json.Unmarshal(d.Payload, v)
+}
+
+// Header returns the value of the header, empty string is returned if it's not set.
+func (d *Delivery) Header(key string) string {
+	return d.Headers[key]
+}
+
+// Ack marks the message consumed.
+func (d *Delivery) Ack() {
+	d.outcome = outcomeAck
+}
+
+// Reject sends the message to the dead-letter topic without retrying.
+func (d *Delivery) Reject(reason error) {
+	d.outcome = outcomeReject
+	d.reason = reason
+}
+
+// Redeliver retries the message later, the message is sent to the dead-letter topic
+// once the retry limit is reached.
+func (d *Delivery) Redeliver(reason error) {
+	d.outcome = outcomeRedeliver
+	d.reason = reason
+}
+
+type DurableHandler func(d *Delivery) error
+
+type DurableOption func(*durableStore)
+
+//export
+// MaxRetries sets how many times the message is redelivered before it's sent to the dead-letter topic.
+func MaxRetries(retries int) DurableOption {
+	return func(ds *durableStore) {
+		ds.maxRetries = retries
+	}
+}
+
+//export
+func RetryDelay(delay time.Duration) DurableOption {
+	return func(ds *durableStore) {
+		ds.retryDelay = delay
+	}
+}
+
+//export
+func DeadLetterTopic(topic string) DurableOption {
+	return func(ds *durableStore) {
+		ds.deadLetter = topic
+	}
+}
+
+//export
+// Retention makes the stored messages expired after d, zero keeps them forever.
+func Retention(d time.Duration) DurableOption {
+	return func(ds *durableStore) {
+		ds.retention = d
+	}
+}
+
+type durableStore struct {
+	db         *buntdb.DB
+	ownDB      bool
+	maxRetries int
+	retryDelay time.Duration
+	deadLetter string
+	retention  time.Duration
+
+	consumers map[string][]*Consumer
+	lock      sync.Mutex
+}
+
+func msgKey(topic string, offset uint64) string {
+	return fmt.Sprintf("%s%s:%0*d", msgKeyPrefix, topic, offsetDigits, offset)
+}
+
+func consumerKey(prefix, topic, consumer string) string {
+	return prefix + topic + ":" + consumer
+}
+
+// isMsgKey checks the key exactly, the key of another topic may be greater than the pivot.
+func isMsgKey(key, topic string) bool {
+	prefix := msgKeyPrefix + topic + ":"
+	return len(key) == len(prefix)+offsetDigits && strings.HasPrefix(key, prefix)
+}
+
+func (ds *durableStore) save(env *Envelope, payload json.RawMessage) (msg storedMessage, err error) {
+	topic := env.Topic
+	msg = storedMessage{ID: env.ID, Topic: topic, Timestamp: env.Timestamp, Headers: env.Headers, Payload: payload}
+	err = ds.db.Update(func(tx *buntdb.Tx) error {
+		var seq uint64
+		if val, err := tx.Get(seqKeyPrefix + topic); err == nil {
+			if seq, err = strconv.ParseUint(val, 10, 64); err != nil {
+				return err
+			}
+		} else if err != buntdb.ErrNotFound {
+			return err
+		}
+		seq++
+		if _, _, err := tx.Set(seqKeyPrefix+topic, strconv.FormatUint(seq, 10), nil); err != nil {
+			return err
+		}
+		msg.Offset = seq
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		var opts *buntdb.SetOptions
+		if ds.retention > 0 {
+			opts = &buntdb.SetOptions{Expires: true, TTL: ds.retention}
+		}
+		_, _, err = tx.Set(msgKey(topic, seq), string(data), opts)
+		return err
+	})
+	return
+}
+
+func (ds *durableStore) publish(envs ...*Envelope) error {
+	for _, env := range envs {
+		payload, err := json.Marshal(env.Payload)
+		if err != nil {
+			return err
+		}
+		if _, err = ds.save(env, payload); err != nil {
+			return err
+		}
+		ds.notify(env.Topic)
+	}
+	return nil
+}
+
+func (ds *durableStore) notify(topic string) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	for _, c := range ds.consumers[topic] {
+		select {
+		case c.notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// next returns the first message after the offset.
+// corruptMessage is the error of the stored message which can't be decoded.
+type corruptMessage struct {
+	offset uint64
+	raw    string
+	err    error
+}
+
+func (cm *corruptMessage) Error() string {
+	return fmt.Sprintf("decode message %d failed : %v", cm.offset, cm.err)
+}
+
+// next returns the message after the offset, *corruptMessage is returned if the message can't be decoded.
+func (ds *durableStore) next(topic string, offset uint64) (msg storedMessage, found bool, err error) {
+	var decodeErr error
+	err = ds.db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendGreaterOrEqual("", msgKey(topic, offset+1), func(key, val string) bool {
+			if !isMsgKey(key, topic) {
+				return false
+			}
+			if err := json.Unmarshal([]byte(val), &msg); err != nil {
+				cm := &corruptMessage{raw: val, err: err}
+				cm.offset, _ = strconv.ParseUint(key[len(key)-offsetDigits:], 10, 64)
+				decodeErr = cm
+				return false
+			}
+			found = true
+			return false
+		})
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	return
+}
+
+func (ds *durableStore) offset(topic, consumer string) (offset uint64, err error) {
+	err = ds.db.View(func(tx *buntdb.Tx) error {
+		val, err := tx.Get(consumerKey(offsetKeyPrefix, topic, consumer))
+		if err == buntdb.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		offset, err = strconv.ParseUint(val, 10, 64)
+		return err
+	})
+	return
+}
+
+// attempts returns the times the message at the offset has been delivered to the consumer.
+func (ds *durableStore) attempts(topic, consumer string, offset uint64) (attempts int) {
+	_ = ds.db.View(func(tx *buntdb.Tx) error {
+		val, err := tx.Get(consumerKey(retryKeyPrefix, topic, consumer))
+		if err != nil {
+			return err
+		}
+		parts := strings.SplitN(val, ":", 2)
+		if len(parts) == 2 && parts[0] == strconv.FormatUint(offset, 10) {
+			attempts, _ = strconv.Atoi(parts[1])
+		}
+		return nil
+	})
+	return
+}
+
+func (ds *durableStore) saveAttempts(topic, consumer string, offset uint64, attempts int) error {
+	return ds.db.Update(func(tx *buntdb.Tx) error {
+		val := strconv.FormatUint(offset, 10) + ":" + strconv.Itoa(attempts)
+		_, _, err := tx.Set(consumerKey(retryKeyPrefix, topic, consumer), val, nil)
+		return err
+	})
+}
+
+func (ds *durableStore) commit(topic, consumer string, offset uint64) error {
+	return ds.db.Update(func(tx *buntdb.Tx) error {
+		if _, err := tx.Delete(consumerKey(retryKeyPrefix, topic, consumer)); err != nil && err != buntdb.ErrNotFound {
+			return err
+		}
+		_, _, err := tx.Set(consumerKey(offsetKeyPrefix, topic, consumer), strconv.FormatUint(offset, 10), nil)
+		return err
+	})
+}
+
+func (ds *durableStore) register(c *Consumer) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	ds.consumers[c.topic] = append(ds.consumers[c.topic], c)
+}
+
+func (ds *durableStore) unregister(c *Consumer) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	list := ds.consumers[c.topic]
+	for i, item := range list {
+		if item == c {
+			ds.consumers[c.topic] = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(ds.consumers[c.topic]) == 0 {
+		delete(ds.consumers, c.topic)
+	}
+}
+
+func (ds *durableStore) allConsumers() []*Consumer {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	var list []*Consumer
+	for _, consumers := range ds.consumers {
+		list = append(list, consumers...)
+	}
+	return list
+}
+
+// Consumer reads the durable messages of a topic in order, the offset of the consumed message
+// is persisted with the consumer name, so the consumer continues from it after restarting.
+type Consumer struct {
+	pxs      *PubXSub
+	topic    string
+	name     string
+	handler  DurableHandler
+	notifyCh chan struct{}
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+	offset   uint64
+	lock     sync.RWMutex
+}
+
+func (c *Consumer) Topic() string {
+	return c.topic
+}
+
+func (c *Consumer) Name() string {
+	return c.name
+}
+
+// Offset returns the offset of the latest consumed message.
+func (c *Consumer) Offset() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.offset
+}
+
+// Close stops the consumer and waits until the handling message is done.
+func (c *Consumer) Close() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+		c.pxs.durable.unregister(c)
+	})
+	<-c.doneCh
+}
+
+func (c *Consumer) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-c.stopCh:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (c *Consumer) run() {
+	defer close(c.doneCh)
+	ds := c.pxs.durable
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		default:
+		}
+		msg, found, err := ds.next(c.topic, c.Offset())
+		if cm, ok := err.(*corruptMessage); ok {
+			c.skip(cm)
+			continue
+		}
+		if err != nil {
+			logx.Errorf("read message of topic %s failed : %v", c.topic, err)
+			if !c.wait(ds.retryDelay) {
+				return
+			}
+			continue
+		}
+		if !found {
+			select {
+			case <-c.stopCh:
+				return
+			case <-c.notifyCh:
+			}
+			continue
+		}
+		if !c.process(msg) {
+			return
+		}
+	}
+}
+
+// process delivers the message until it's acknowledged or sent to the dead-letter topic,
+// false is returned if the consumer is stopped.
+func (c *Consumer) process(msg storedMessage) bool {
+	ds := c.pxs.durable
+	attempts := ds.attempts(c.topic, c.name, msg.Offset)
+	for {
+		attempts++
+		d := &Delivery{
+			ID:        msg.ID,
+			Topic:     msg.Topic,
+			Offset:    msg.Offset,
+			Timestamp: msg.Timestamp,
+			Headers:   msg.Headers,
+			Payload:   msg.Payload,
+			Consumer:  c.name,
+			Attempts:  attempts,
+		}
+		c.handle(d)
+
+		switch d.outcome {
+		case outcomeRedeliver:
+			if attempts > ds.maxRetries {
+				c.deadLetter(d)
+				c.commit(msg.Offset)
+				return true
+			}
+			logx.CaptureError(ds.saveAttempts(c.topic, c.name, msg.Offset, attempts))
+			if !c.wait(ds.retryDelay) {
+				return false
+			}
+		case outcomeReject:
+			c.deadLetter(d)
+			c.commit(msg.Offset)
+			return true
+		default:
+			c.commit(msg.Offset)
+			return true
+		}
+	}
+}
+
+// skip sends the corrupt message to the dead-letter topic and moves on, it can never be decoded.
+func (c *Consumer) skip(cm *corruptMessage) {
+	raw, _ := json.Marshal(cm.raw)
+	c.deadLetter(&Delivery{
+		Topic:    c.topic,
+		Offset:   cm.offset,
+		Payload:  raw,
+		Consumer: c.name,
+		Attempts: 1,
+		reason:   cm,
+	})
+	c.commit(cm.offset)
+}
+
+func (c *Consumer) handle(d *Delivery) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.Redeliver(fmt.Errorf("panic found during consuming %s : %v", d.Topic, r))
+		}
+	}()
+	err := c.handler(d)
+	if d.outcome == outcomeNone {
+		if err != nil {
+			d.Redeliver(err)
+		} else {
+			d.Ack()
+		}
+	}
+}
+
+func (c *Consumer) deadLetter(d *Delivery) {
+	dl := DeadLetter{
+		ID:       d.ID,
+		Topic:    d.Topic,
+		Offset:   d.Offset,
+		Consumer: d.Consumer,
+		Attempts: d.Attempts,
+		Payload:  d.Payload,
+	}
+	if d.reason != nil {
+		dl.Reason = d.reason.Error()
+	}
+	logx.Warnf("message %d of topic %s is sent to dead-letter topic : %s", d.Offset, d.Topic, dl.Reason)
+	if d.Topic == c.pxs.durable.deadLetter {
+		// never loop on the dead-letter topic
+		return
+	}
+	if err := c.pxs.PublishE(dl, c.pxs.durable.deadLetter); err != nil {
+		logx.Errorf("publish message %d of topic %s to dead-letter topic failed : %v", d.Offset, d.Topic, err)
+	}
+}
+
+func (c *Consumer) commit(offset uint64) {
+	if err := c.pxs.durable.commit(c.topic, c.name, offset); err != nil {
+		logx.Errorf("commit offset %d of topic %s failed : %v", offset, c.topic, err)
+	}
+	c.lock.Lock()
+	c.offset = offset
+	c.lock.Unlock()
+}
+
+//export
+// NewDurable returns the pubsub in durable mode, the published messages are also stored in the buntdb file.
+func NewDurable(filename string, capacity int, opts ...DurableOption) (*PubXSub, error) {
+	db, err := buntdb.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	pxs := NewDurableWithDB(db, capacity, opts...)
+	pxs.durable.ownDB = true
+	return pxs, nil
+}
+
+//export
+func NewDurableWithDB(db *buntdb.DB, capacity int, opts ...DurableOption) *PubXSub {
+	ds := &durableStore{
+		db:         db,
+		maxRetries: defaultMaxRetries,
+		retryDelay: defaultRetryDelay,
+		deadLetter: DefaultDeadLetterTopic,
+		consumers:  make(map[string][]*Consumer),
+	}
+	for _, opt := range opts {
+		opt(ds)
+	}
+	pxs := New(capacity)
+	pxs.durable = ds
+	return pxs
+}
+
+// IsDurable reports whether the pubsub is in durable mode.
+func (pxs *PubXSub) IsDurable() bool {
+	return pxs.durable != nil
+}
+
+// Consume starts the durable consumer of the topic, the consumers with different names
+// receive all the messages independently.
+func (pxs *PubXSub) Consume(topic string, consumer string, handler DurableHandler) (*Consumer, error) {
+	if pxs.durable == nil {
+		return nil, ErrNotDurable
+	}
+	offset, err := pxs.durable.offset(topic, consumer)
+	if err != nil {
+		return nil, err
+	}
+	c := &Consumer{
+		pxs:      pxs,
+		topic:    topic,
+		name:     consumer,
+		handler:  handler,
+		notifyCh: make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+		offset:   offset,
+	}
+	pxs.durable.register(c)
+	go c.run()
+	return c, nil
+}
+
+// Close stops the durable consumers and closes the buntdb opened by NewDurable.
+func (pxs *PubXSub) Close() error {
+	if pxs.durable == nil {
+		return nil
+	}
+	for _, c := range pxs.durable.allConsumers() {
+		c.Close()
+	}
+	if pxs.durable.ownDB {
+		return pxs.durable.db.Close()
+	}
+	return nil
+}
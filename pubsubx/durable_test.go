@@ -0,0 +1,245 @@
+package pubsubx
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tidwall/buntdb"
+)
+
+type order struct {
+	No string `json:"no"`
+}
+
+func TestDurable_Consume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pubsubx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "pubsub.db")
+
+	pxs, err := NewDurable(file, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 10)
+	handler := func(d *Delivery) error {
+		var o order
+		if err := d.Decode(&o); err != nil {
+			return err
+		}
+		received <- o.No
+		return nil
+	}
+	if _, err = pxs.Consume("order", "billing", handler); err != nil {
+		t.Fatal(err)
+	}
+	pxs.Publish(order{"1"}, "order")
+	if no := receive(t, received); no != "1" {
+		t.Errorf("received order %s, want 1", no)
+	}
+	if err = pxs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the messages published while the consumer is down are delivered after restarting
+	pxs, err = NewDurable(file, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pxs.Close()
+	pxs.Publish(order{"2"}, "order")
+	pxs.Publish(order{"3"}, "order")
+	c, err := pxs.Consume("order", "billing", handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"2", "3"} {
+		if no := receive(t, received); no != want {
+			t.Errorf("received order %s after restarting, want %s", no, want)
+		}
+	}
+
+	// a new consumer starts from the beginning
+	if _, err = pxs.Consume("order", "audit", handler); err != nil {
+		t.Fatal(err)
+	}
+	if no := receive(t, received); no != "1" {
+		t.Errorf("new consumer received order %s, want 1", no)
+	}
+	c.Close()
+	if offset := c.Offset(); offset != 3 {
+		t.Errorf("Offset() = %d, want 3", offset)
+	}
+
+	if _, err = New(10).Consume("order", "billing", handler); err != ErrNotDurable {
+		t.Errorf("Consume() of the memory pubsub = %v, want %v", err, ErrNotDurable)
+	}
+}
+
+func TestDurable_DeadLetter(t *testing.T) {
+	pxs, err := NewDurable(":memory:", 10, MaxRetries(2), RetryDelay(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pxs.Close()
+
+	letters := make(chan DeadLetter, 10)
+	_, err = pxs.Consume(DefaultDeadLetterTopic, "monitor", func(d *Delivery) error {
+		var dl DeadLetter
+		if err := d.Decode(&dl); err != nil {
+			return err
+		}
+		letters <- dl
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := make(map[string]int)
+	_, err = pxs.Consume("order", "billing", func(d *Delivery) error {
+		var o order
+		_ = d.Decode(&o)
+		attempts[o.No] = d.Attempts
+		switch o.No {
+		case "rejected":
+			d.Reject(errors.New("invalid order"))
+		case "failed":
+			return errors.New("failed")
+		case "panic":
+			panic("oops")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pxs.Publish(order{"rejected"}, "order")
+	pxs.Publish(order{"failed"}, "order")
+	pxs.Publish(order{"ok"}, "order")
+
+	if dl := receiveLetter(t, letters); dl.Reason != "invalid order" || dl.Offset != 1 {
+		t.Errorf("dead letter of the rejected order = %+v", dl)
+	}
+	if dl := receiveLetter(t, letters); dl.Reason != "failed" || dl.Attempts != 3 || dl.Consumer != "billing" {
+		t.Errorf("dead letter of the failed order = %+v", dl)
+	}
+
+	pxs.Publish(order{"panic"}, "order")
+	if dl := receiveLetter(t, letters); !strings.Contains(dl.Reason, "oops") {
+		t.Errorf("dead letter of the panic = %+v", dl)
+	}
+	if attempts["ok"] != 1 {
+		t.Errorf("attempts of the ok order = %d, want 1", attempts["ok"])
+	}
+}
+
+func TestDurable_CorruptMessage(t *testing.T) {
+	db, err := buntdb.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	pxs := NewDurableWithDB(db, 10)
+	defer pxs.Close()
+
+	pxs.Publish(order{"1"}, "order")
+	pxs.Publish(order{"2"}, "order")
+	err = db.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(msgKey("order", 1), "{corrupt", nil)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	letters := make(chan DeadLetter, 10)
+	_, err = pxs.Consume(DefaultDeadLetterTopic, "monitor", func(d *Delivery) error {
+		var dl DeadLetter
+		if err := d.Decode(&dl); err != nil {
+			return err
+		}
+		letters <- dl
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 10)
+	c, err := pxs.Consume("order", "billing", func(d *Delivery) error {
+		var o order
+		if err := d.Decode(&o); err != nil {
+			return err
+		}
+		received <- o.No
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dl := receiveLetter(t, letters); dl.Offset != 1 || dl.Consumer != "billing" || !strings.Contains(dl.Reason, "decode message 1 failed") {
+		t.Errorf("dead letter of the corrupt message = %+v", dl)
+	}
+	if no := receive(t, received); no != "2" {
+		t.Errorf("received order %s after the corrupt message, want 2", no)
+	}
+	if offset := c.Offset(); offset != 2 {
+		t.Errorf("Offset() = %d, want 2", offset)
+	}
+}
+
+func receive(t *testing.T, ch chan string) string {
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(2 * time.Second):
+		t.Fatal("message is not received")
+	}
+	return ""
+}
+
+func receiveLetter(t *testing.T, ch chan DeadLetter) DeadLetter {
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(2 * time.Second):
+		t.Fatal("dead letter is not received")
+	}
+	return DeadLetter{}
+}
+
+func TestDurable_PublishError(t *testing.T) {
+	pxs, err := NewDurable(":memory:", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string, 10)
+	pxs.Subscribe("order", func(msg interface{}) error {
+		received <- "received"
+		return nil
+	})
+
+	if err := pxs.PublishE(make(chan int), "order"); err == nil {
+		t.Error("PublishE() of the message which can't be marshaled returns nil error")
+	}
+	if err := pxs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := pxs.PublishWithHeadersE(map[string]string{"tenant": "eu"}, order{"1"}, "order"); err == nil {
+		t.Error("PublishWithHeadersE() to the closed store returns nil error")
+	}
+	select {
+	case <-received:
+		t.Error("the message which is not stored is sent to the subscriber")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -1,44 +1,53 @@
 package pubsubx
 
 import (
-	"github.com/cskr/pubsub"
-
 	"github.com/fidelfly/gox/logx"
 )
 
 type PubXSub struct {
-	ps *pubsub.PubSub
+	ps      *broker
+	durable *durableStore
+	replies replyRouter
 }
 
 type SubscriberHandler func(msg interface{}) error
 type Subscriber chan interface{}
 
 func New(capacity int) *PubXSub {
-	return &PubXSub{ps: pubsub.New(capacity)}
+	return &PubXSub{ps: newBroker(capacity)}
 }
 
+// Subscribe receives the messages of the topic, the topic can be a pattern with the wildcards,
+// see SingleWildcard and MultiWildcard. The returned subscriber carries the published messages
+// as they are, use SubscribeContext to get the envelopes. The request published by Request is
+// received as *Envelope, so the handler is able to reply it.
 func (pxs *PubXSub) Subscribe(topic string, handlers ...SubscriberHandler) Subscriber {
-	subch := pxs.ps.Sub(topic)
+	config := pxs.subscribeConfig()
+	config.payload = true
+	sub := pxs.ps.sub(config, nil, topic)
 	go func() {
-		defer func() {
-			if err := recover(); err != nil {
-				logx.Error(err)
-			}
-		}()
-		for {
-			if msg, ok := <-subch; ok {
-				for _, handler := range handlers {
-					_ = handler(msg)
-				}
-			} else {
-				break
-			}
+		for msg := range sub {
+			handleMessage(topic, handlers, msg)
 		}
+	}()
+	return sub
+}
 
+func handleMessage(topic string, handlers []SubscriberHandler, msg interface{}) {
+	defer func() {
+		if err := recover(); err != nil {
+			logx.Errorf("panic found during handling message of %s : %v", topic, err)
+		}
 	}()
-	return subch
+	for _, handler := range handlers {
+		if err := handler(msg); err != nil {
+			logx.Warnf("subscriber handler failed : %v", err)
+		}
+	}
 }
 
+// UnSubscribe removes the topics or patterns exactly as they're subscribed, the pattern doesn't remove
+// the topics it matches. All the topics are removed if no topic is specified.
 func (pxs *PubXSub) UnSubscribe(sub Subscriber, topics ...string) {
 	go func() {
 		defer func() {
@@ -46,17 +55,64 @@ func (pxs *PubXSub) UnSubscribe(sub Subscriber, topics ...string) {
 				logx.Error(err)
 			}
 		}()
-		pxs.ps.Unsub(sub, topics...)
+		pxs.ps.unsub(sub, topics...)
 	}()
 }
 
+// Subscribers returns how many subscribers receive the messages of the topic, including the patterns matching it.
+func (pxs *PubXSub) Subscribers(topic string) int {
+	return pxs.ps.count(topic)
+}
+
+// Publish sends the message to the subscribers in background. In durable mode, the message is stored
+// before it returns, so it must be able to be marshaled to JSON. The storing error is only logged,
+// use PublishE to get it.
 func (pxs *PubXSub) Publish(msg interface{}, topics ...string) {
+	pxs.PublishWithHeaders(nil, msg, topics...)
+}
+
+// PublishWithHeaders publishes the message with the headers of the envelope.
+func (pxs *PubXSub) PublishWithHeaders(headers map[string]string, msg interface{}, topics ...string) {
+	if err := pxs.PublishWithHeadersE(headers, msg, topics...); err != nil {
+		logx.Errorf("publish message to %v failed : %v", topics, err)
+	}
+}
+
+// PublishE is like Publish, but returns the error of storing the message in durable mode.
+// The message is not sent to the subscribers if it's not stored.
+func (pxs *PubXSub) PublishE(msg interface{}, topics ...string) error {
+	return pxs.PublishWithHeadersE(nil, msg, topics...)
+}
+
+// PublishWithHeadersE is like PublishWithHeaders, but returns the error of storing the message in durable mode.
+func (pxs *PubXSub) PublishWithHeadersE(headers map[string]string, msg interface{}, topics ...string) error {
+	envs := make([]*Envelope, len(topics))
+	for i, topic := range topics {
+		envs[i] = newEnvelope(topic, msg, headers)
+	}
+	return pxs.publish(envs...)
+}
+
+func (pxs *PubXSub) publish(envs ...*Envelope) error {
+	if pxs.durable != nil {
+		if err := pxs.durable.publish(envs...); err != nil {
+			return err
+		}
+	}
+	pxs.dispatch(envs...)
+	return nil
+}
+
+// dispatch sends the envelopes to the subscribers in background.
+func (pxs *PubXSub) dispatch(envs ...*Envelope) {
 	go func() {
 		defer func() {
 			if err := recover(); err != nil {
 				logx.Error(err)
 			}
 		}()
-		pxs.ps.Pub(msg, topics...)
+		for _, env := range envs {
+			pxs.ps.pub(env)
+		}
 	}()
 }
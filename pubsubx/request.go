@@ -0,0 +1,126 @@
+package pubsubx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/fidelfly/gox/pkg/randx"
+)
+
+// Headers used by the request/reply
+const (
+	HeaderReplyTo       = "reply-to"
+	HeaderCorrelationID = "correlation-id"
+	HeaderReplyError    = "reply-error"
+
+	replyTopicPrefix = "_reply."
+)
+
+var ErrNoReplyTo = errors.New("message is not a request")
+
+// Replyable is the message which carries the headers of the request, it's the *Envelope received by the subscriber.
+type Replyable interface {
+	Header(key string) string
+}
+
+// replyRouter routes the replies to the waiting requests directly, the replies never go through the broker,
+// so they can't be received by the subscribers of the wildcard patterns.
+type replyRouter struct {
+	topic   string
+	pending map[string]chan *Envelope
+	lock    sync.Mutex
+}
+
+// replyTopic returns the reply topic of the pubsub, it's generated once on the first request.
+func (rr *replyRouter) replyTopic() string {
+	rr.lock.Lock()
+	defer rr.lock.Unlock()
+	if len(rr.topic) == 0 {
+		rr.topic = replyTopicPrefix + randx.GenUUID("reply")
+		rr.pending = make(map[string]chan *Envelope)
+	}
+	return rr.topic
+}
+
+func (rr *replyRouter) wait(correlationID string) chan *Envelope {
+	ch := make(chan *Envelope, 1)
+	rr.lock.Lock()
+	rr.pending[correlationID] = ch
+	rr.lock.Unlock()
+	return ch
+}
+
+func (rr *replyRouter) cancel(correlationID string) {
+	rr.lock.Lock()
+	delete(rr.pending, correlationID)
+	rr.lock.Unlock()
+}
+
+// route sends the first reply of the request to the requester, the others are dropped.
+func (rr *replyRouter) route(reply *Envelope) {
+	correlationID := reply.Header(HeaderCorrelationID)
+	rr.lock.Lock()
+	ch, ok := rr.pending[correlationID]
+	delete(rr.pending, correlationID)
+	rr.lock.Unlock()
+	if ok {
+		ch <- reply
+	}
+}
+
+// Request publishes the message to the topic and waits for the first reply, the reply is correlated
+// by the id of the request message. The context error is returned if there's no reply before ctx is done.
+// The request and the reply are not stored in durable mode, so the durable consumers don't receive the request.
+func (pxs *PubXSub) Request(ctx context.Context, topic string, msg interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	env := newEnvelope(topic, msg, nil)
+	env.Headers = map[string]string{HeaderReplyTo: pxs.replies.replyTopic(), HeaderCorrelationID: env.ID}
+
+	replies := pxs.replies.wait(env.ID)
+	defer pxs.replies.cancel(env.ID)
+	pxs.dispatch(env)
+
+	select {
+	case reply := <-replies:
+		if errMsg := reply.Header(HeaderReplyError); len(errMsg) > 0 {
+			return nil, errors.New(errMsg)
+		}
+		return reply.Payload, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Reply sends the response of the request message to the requester.
+func (pxs *PubXSub) Reply(req Replyable, msg interface{}) error {
+	return pxs.reply(req, msg, nil)
+}
+
+// ReplyError makes the Request of the requester return the error.
+func (pxs *PubXSub) ReplyError(req Replyable, err error) error {
+	return pxs.reply(req, nil, map[string]string{HeaderReplyError: err.Error()})
+}
+
+func (pxs *PubXSub) reply(req Replyable, msg interface{}, headers map[string]string) error {
+	replyTopic := req.Header(HeaderReplyTo)
+	correlationID := req.Header(HeaderCorrelationID)
+	if len(replyTopic) == 0 || len(correlationID) == 0 {
+		return ErrNoReplyTo
+	}
+	env := newEnvelope(replyTopic, msg, headers)
+	if env.Headers == nil {
+		env.Headers = make(map[string]string)
+	}
+	env.Headers[HeaderCorrelationID] = correlationID
+	if strings.HasPrefix(replyTopic, replyTopicPrefix) {
+		// the reply topic is reserved, the reply of the unknown request is dropped
+		pxs.replies.route(env)
+		return nil
+	}
+	pxs.dispatch(env)
+	return nil
+}
@@ -0,0 +1,142 @@
+package pubsubx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPubXSub_Request(t *testing.T) {
+	pxs := New(10)
+	pxs.SubscribeContext(context.Background(), "math.#", func(ctx context.Context, env *Envelope) error {
+		n := env.Payload.(int)
+		if n < 0 {
+			return pxs.ReplyError(env, errors.New("negative number"))
+		}
+		return pxs.Reply(env, n*n)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reply, err := pxs.Request(ctx, "math.square", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != 9 {
+		t.Errorf("Request() = %v, want 9", reply)
+	}
+
+	if _, err = pxs.Request(ctx, "math.square", -1); err == nil || err.Error() != "negative number" {
+		t.Errorf("Request() of the error reply = %v, want negative number", err)
+	}
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer timeoutCancel()
+	if _, err = pxs.Request(timeoutCtx, "nobody", 1); err != context.DeadlineExceeded {
+		t.Errorf("Request() without the responder = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if err = pxs.Reply(newEnvelope("math.square", 1, nil), 1); err != ErrNoReplyTo {
+		t.Errorf("Reply() to the message without reply-to = %v, want %v", err, ErrNoReplyTo)
+	}
+}
+
+func TestPubXSub_RequestChained(t *testing.T) {
+	pxs := New(1)
+	pxs.SubscribeContext(context.Background(), "order.price", func(ctx context.Context, env *Envelope) error {
+		return pxs.Reply(env, env.Payload.(int)*10)
+	})
+	pxs.SubscribeContext(context.Background(), "order.total", func(ctx context.Context, env *Envelope) error {
+		price, err := pxs.Request(ctx, "order.price", env.Payload)
+		if err != nil {
+			return pxs.ReplyError(env, err)
+		}
+		return pxs.Reply(env, price.(int)+1)
+	}, Concurrency(4))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if total, err := pxs.Request(ctx, "order.total", n); err != nil || total != n*10+1 {
+				t.Errorf("Request(%d) = %v, %v, want %d", n, total, err, n*10+1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// the replies are routed without subscribing the reply topic
+	pxs.ps.lock.RLock()
+	subs := len(pxs.ps.subs)
+	pxs.ps.lock.RUnlock()
+	if subs != 2 {
+		t.Errorf("count of the subscriptions = %d, want 2", subs)
+	}
+}
+
+func TestPubXSub_ReplyWildcard(t *testing.T) {
+	pxs := New(10)
+	leaked := make(chan string, 10)
+	pxs.SubscribeContext(context.Background(), "#", func(ctx context.Context, env *Envelope) error {
+		if env.Topic != "math.square" {
+			leaked <- env.Topic
+		}
+		return nil
+	})
+	pxs.SubscribeContext(context.Background(), "math.square", func(ctx context.Context, env *Envelope) error {
+		return pxs.Reply(env, env.Payload.(int)*env.Payload.(int))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if reply, err := pxs.Request(ctx, "math.square", 3); err != nil || reply != 9 {
+		t.Errorf("Request() = %v, %v, want 9", reply, err)
+	}
+	select {
+	case topic := <-leaked:
+		t.Errorf("the reply of %s is received by the wildcard subscriber", topic)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPubXSub_RequestDurable(t *testing.T) {
+	pxs, err := NewDurable(":memory:", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pxs.Close()
+	consumed := make(chan string, 10)
+	_, err = pxs.Consume("order.check", "checker", func(d *Delivery) error {
+		consumed <- d.ID
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pxs.Subscribe("order.check", func(msg interface{}) error {
+		if req, ok := msg.(*Envelope); ok {
+			return pxs.Reply(req, "checked "+req.Payload.(order).No)
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if reply, err := pxs.Request(ctx, "order.check", order{"1"}); err != nil || reply != "checked 1" {
+		t.Errorf("Request() in durable mode = %v, %v", reply, err)
+	}
+	// the request is not stored, so the durable consumer never receives it
+	if _, found, err := pxs.durable.next("order.check", 0); found || err != nil {
+		t.Errorf("the request is stored, found = %v, err = %v", found, err)
+	}
+	select {
+	case id := <-consumed:
+		t.Errorf("the request %s is consumed by the durable consumer", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,131 @@
+package pubsubx
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/fidelfly/gox/logx"
+	"github.com/fidelfly/gox/pkg/randx"
+)
+
+// Envelope wraps the published message with its metadata.
+type Envelope struct {
+	ID        string
+	Topic     string
+	Timestamp time.Time
+	Headers   map[string]string
+	Payload   interface{}
+}
+
+func newEnvelope(topic string, msg interface{}, headers map[string]string) *Envelope {
+	return &Envelope{
+		ID:        randx.GenUUID(topic),
+		Topic:     topic,
+		Timestamp: time.Now(),
+		Headers:   headers,
+		Payload:   msg,
+	}
+}
+
+// Header returns the value of the header, empty string is returned if it's not set.
+func (env *Envelope) Header(key string) string {
+	return env.Headers[key]
+}
+
+// Handler handles the message with the context of the subscription, which is canceled once
+// the subscription is removed.
+type Handler func(ctx context.Context, env *Envelope) error
+
+// OverflowPolicy decides what to do when the bounded capacity of the subscriber is full.
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // the publisher waits until there's room
+	OverflowDropOldest                       // the oldest pending message is dropped
+	OverflowDropNewest                       // the new message is dropped
+)
+
+type subscribeConfig struct {
+	capacity    int
+	concurrency int
+	overflow    OverflowPolicy
+	payload     bool // the payload instead of the envelope is sent to the subscriber
+}
+
+type SubscribeOption func(*subscribeConfig)
+
+//export
+// Concurrency sets how many workers handle the messages of the subscriber, the messages are
+// not handled in order if it's greater than 1.
+func Concurrency(workers int) SubscribeOption {
+	return func(sc *subscribeConfig) {
+		sc.concurrency = workers
+	}
+}
+
+//export
+// Capacity sets the count of the pending messages, the capacity of the PubXSub is used by default.
+func Capacity(capacity int) SubscribeOption {
+	return func(sc *subscribeConfig) {
+		sc.capacity = capacity
+	}
+}
+
+//export
+func Overflow(policy OverflowPolicy) SubscribeOption {
+	return func(sc *subscribeConfig) {
+		sc.overflow = policy
+	}
+}
+
+func (pxs *PubXSub) subscribeConfig() subscribeConfig {
+	return subscribeConfig{capacity: pxs.ps.capacity, concurrency: 1}
+}
+
+// SubscribeContext receives the messages of the topic or the pattern, the subscription is removed
+// once ctx is done. The panic of the handler is recovered and the subscription keeps working.
+// Unlike Subscribe, the returned subscriber carries the *Envelope of the messages.
+func (pxs *PubXSub) SubscribeContext(ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) Subscriber {
+	config := pxs.subscribeConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.concurrency < 1 {
+		config.concurrency = 1
+	}
+	if config.overflow != OverflowBlock && config.capacity < 1 {
+		// the policy needs a buffer to drop the message from
+		config.capacity = 1
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	sub := pxs.ps.sub(config, cancel, topic)
+	go func() {
+		<-subCtx.Done()
+		pxs.ps.unsub(sub)
+	}()
+	for i := 0; i < config.concurrency; i++ {
+		go func() {
+			for msg := range sub {
+				if env, ok := msg.(*Envelope); ok {
+					handleEnvelope(subCtx, handler, env)
+				}
+			}
+		}()
+	}
+	return sub
+}
+
+func handleEnvelope(ctx context.Context, handler Handler, env *Envelope) {
+	defer func() {
+		if r := recover(); r != nil {
+			buf := make([]byte, 64<<10)
+			buf = buf[:runtime.Stack(buf, false)]
+			logx.Errorf("panic found during handling message %s of %s : %v\n%s", env.ID, env.Topic, r, buf)
+		}
+	}()
+	if err := handler(ctx, env); err != nil {
+		logx.Warnf("handle message %s of %s failed : %v", env.ID, env.Topic, err)
+	}
+}
@@ -0,0 +1,134 @@
+package pubsubx
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPubXSub_SubscribeContext(t *testing.T) {
+	pxs := New(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 10)
+	sub := pxs.SubscribeContext(ctx, "order.#", func(ctx context.Context, env *Envelope) error {
+		if env.Payload == "panic" {
+			panic("oops")
+		}
+		if env.Topic != "order.created" || len(env.ID) == 0 {
+			t.Errorf("envelope = %+v", env)
+		}
+		received <- env.Header("tenant") + ":" + env.Payload.(string)
+		return nil
+	})
+
+	if n := pxs.Subscribers("order.created"); n != 1 {
+		t.Errorf("Subscribers(order.created) = %d, want 1", n)
+	}
+	if n := pxs.Subscribers("invoice"); n != 0 {
+		t.Errorf("Subscribers(invoice) = %d, want 0", n)
+	}
+
+	// the subscription is still alive after the panic
+	pxs.Publish("panic", "order.created")
+	pxs.PublishWithHeaders(map[string]string{"tenant": "eu"}, "created", "order.created")
+	if msg := receive(t, received); msg != "eu:created" {
+		t.Errorf("received %s, want eu:created", msg)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-sub:
+		if ok {
+			t.Error("subscriber is not closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription is not removed")
+	}
+	if n := pxs.Subscribers("order.created"); n != 0 {
+		t.Errorf("Subscribers(order.created) = %d after the subscription is removed, want 0", n)
+	}
+}
+
+func TestPubXSub_Concurrency(t *testing.T) {
+	pxs := New(10)
+	var running, max int32
+	done := make(chan string, 10)
+	pxs.SubscribeContext(context.Background(), "job", func(ctx context.Context, env *Envelope) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		done <- env.Payload.(string)
+		return nil
+	}, Concurrency(3))
+
+	for _, msg := range []string{"a", "b", "c"} {
+		pxs.Publish(msg, "job")
+	}
+	for i := 0; i < 3; i++ {
+		receive(t, done)
+	}
+	if m := atomic.LoadInt32(&max); m <= 1 {
+		t.Errorf("max running handlers = %d, want more than 1", m)
+	}
+}
+
+func TestPubXSub_Overflow(t *testing.T) {
+	for policy, expected := range map[OverflowPolicy][]string{
+		OverflowDropNewest: {"1", "2"},
+		OverflowDropOldest: {"1", "4"},
+	} {
+		pxs := New(10)
+		release := make(chan struct{})
+		received := make(chan string, 10)
+		started := make(chan struct{}, 10)
+		pxs.SubscribeContext(context.Background(), "topic", func(ctx context.Context, env *Envelope) error {
+			started <- struct{}{}
+			<-release
+			received <- env.Payload.(string)
+			return nil
+		}, Capacity(1), Overflow(policy))
+
+		// "1" is being handled, the others are published to the subscriber with one slot
+		pxs.ps.pub(newEnvelope("topic", "1", nil))
+		<-started
+		for _, msg := range []string{"2", "3", "4"} {
+			pxs.ps.pub(newEnvelope("topic", msg, nil))
+		}
+		close(release)
+		for _, msg := range expected {
+			if got := receive(t, received); got != msg {
+				t.Errorf("policy %v received %s, want %s", policy, got, msg)
+			}
+		}
+		select {
+		case msg := <-received:
+			t.Fatalf("unexpected message %s", msg)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func TestPubXSub_SubscribeInHandler(t *testing.T) {
+	pxs := New(0)
+	done := make(chan string, 10)
+	pxs.SubscribeContext(context.Background(), "order", func(ctx context.Context, env *Envelope) error {
+		// the next message is being sent to this subscriber while it subscribes
+		time.Sleep(20 * time.Millisecond)
+		sub := pxs.Subscribe("order." + env.Payload.(string))
+		pxs.UnSubscribe(sub)
+		done <- env.Payload.(string)
+		return nil
+	})
+
+	pxs.Publish("1", "order")
+	pxs.Publish("2", "order")
+	receive(t, done)
+	receive(t, done)
+}
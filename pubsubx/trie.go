@@ -0,0 +1,99 @@
+package pubsubx
+
+import "strings"
+
+// Wildcards of the topic pattern, the levels of the topic are separated by TopicSeparator.
+// "order.*" matches "order.created" but not "order.created.eu",
+// "order.#" matches "order", "order.created" and "order.created.eu".
+const (
+	TopicSeparator = "."
+	SingleWildcard = "*" // matches exactly one level
+	MultiWildcard  = "#" // matches zero or more levels
+)
+
+// IsPattern reports whether the topic contains the wildcards.
+func IsPattern(topic string) bool {
+	for _, level := range strings.Split(topic, TopicSeparator) {
+		if level == SingleWildcard || level == MultiWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+type trieNode struct {
+	children map[string]*trieNode
+	subs     map[Subscriber]struct{}
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: make(map[string]*trieNode), subs: make(map[Subscriber]struct{})}
+}
+
+// topicTrie indexes the subscribers by the levels of the topic pattern.
+type topicTrie struct {
+	root *trieNode
+}
+
+func newTopicTrie() *topicTrie {
+	return &topicTrie{root: newTrieNode()}
+}
+
+func (tt *topicTrie) add(pattern string, sub Subscriber) {
+	node := tt.root
+	for _, level := range strings.Split(pattern, TopicSeparator) {
+		child, ok := node.children[level]
+		if !ok {
+			child = newTrieNode()
+			node.children[level] = child
+		}
+		node = child
+	}
+	node.subs[sub] = struct{}{}
+}
+
+func (tt *topicTrie) remove(pattern string, sub Subscriber) {
+	tt.root.remove(strings.Split(pattern, TopicSeparator), sub)
+}
+
+// remove deletes the subscriber and reports whether the node becomes empty.
+func (tn *trieNode) remove(levels []string, sub Subscriber) bool {
+	if len(levels) == 0 {
+		delete(tn.subs, sub)
+	} else if child, ok := tn.children[levels[0]]; ok && child.remove(levels[1:], sub) {
+		delete(tn.children, levels[0])
+	}
+	return len(tn.subs) == 0 && len(tn.children) == 0
+}
+
+// match returns the subscribers whose patterns match the topic, each subscriber is returned once.
+func (tt *topicTrie) match(topic string) []Subscriber {
+	found := make(map[Subscriber]struct{})
+	tt.root.match(strings.Split(topic, TopicSeparator), found)
+	subs := make([]Subscriber, 0, len(found))
+	for sub := range found {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+func (tn *trieNode) match(levels []string, found map[Subscriber]struct{}) {
+	if multi, ok := tn.children[MultiWildcard]; ok {
+		// "#" consumes any number of the remaining levels
+		for i := 0; i <= len(levels); i++ {
+			multi.match(levels[i:], found)
+		}
+	}
+	if len(levels) == 0 {
+		for sub := range tn.subs {
+			found[sub] = struct{}{}
+		}
+		return
+	}
+	if child, ok := tn.children[levels[0]]; ok {
+		child.match(levels[1:], found)
+	}
+	if single, ok := tn.children[SingleWildcard]; ok {
+		single.match(levels[1:], found)
+	}
+}
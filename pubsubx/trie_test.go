@@ -0,0 +1,94 @@
+package pubsubx
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestTopicTrie_Match(t *testing.T) {
+	trie := newTopicTrie()
+	subs := map[string]Subscriber{}
+	for _, pattern := range []string{"order.created.eu", "order.*", "order.#", "order.*.eu", "#", "*.created.#", "user.*"} {
+		sub := make(Subscriber)
+		subs[pattern] = sub
+		trie.add(pattern, sub)
+	}
+	matched := func(topic string) []string {
+		var patterns []string
+		for _, sub := range trie.match(topic) {
+			for pattern, s := range subs {
+				if s == sub {
+					patterns = append(patterns, pattern)
+				}
+			}
+		}
+		sort.Strings(patterns)
+		return patterns
+	}
+
+	tests := []struct {
+		topic string
+		want  []string
+	}{
+		{"order.created.eu", []string{"#", "*.created.#", "order.#", "order.*.eu", "order.created.eu"}},
+		{"order.created", []string{"#", "*.created.#", "order.#", "order.*"}},
+		{"order", []string{"#", "order.#"}},
+		{"user.login", []string{"#", "user.*"}},
+	}
+	for _, tt := range tests {
+		if got := matched(tt.topic); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("match(%s) = %v, want %v", tt.topic, got, tt.want)
+		}
+	}
+
+	trie.remove("order.#", subs["order.#"])
+	trie.remove("#", subs["#"])
+	if got := matched("order.deleted"); !reflect.DeepEqual(got, []string{"order.*"}) {
+		t.Errorf("match(order.deleted) = %v, want [order.*]", got)
+	}
+	trie.remove("order.*", subs["order.*"])
+	if got := matched("order.deleted"); len(got) != 0 {
+		t.Errorf("match(order.deleted) = %v, want empty", got)
+	}
+	if IsPattern("order.created") || !IsPattern("order.#") {
+		t.Error("IsPattern() doesn't tell the wildcards")
+	}
+}
+
+func TestPubXSub_Pattern(t *testing.T) {
+	pxs := New(10)
+	received := make(chan string, 10)
+	sub := pxs.Subscribe("order.*", func(msg interface{}) error {
+		received <- msg.(string)
+		return nil
+	})
+	pxs.Publish("created", "order.created")
+	if msg := receive(t, received); msg != "created" {
+		t.Errorf("received %s, want created", msg)
+	}
+	pxs.Publish("eu", "order.created.eu")
+	pxs.Publish("deleted", "order.deleted")
+	if msg := receive(t, received); msg != "deleted" {
+		t.Errorf("received %s, want deleted", msg)
+	}
+
+	// the exact topic matched by the pattern is not subscribed, so nothing is removed
+	pxs.ps.unsub(sub, "order.created")
+	pxs.Publish("updated", "order.updated")
+	if msg := receive(t, received); msg != "updated" {
+		t.Errorf("received %s, want updated", msg)
+	}
+
+	pxs.ps.unsub(sub, "order.*")
+	if _, ok := <-sub; ok {
+		t.Error("subscriber is not closed")
+	}
+	pxs.Publish("ignored", "order.updated")
+	select {
+	case msg := <-received:
+		t.Fatalf("unexpected message %s", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}